@@ -1,16 +1,21 @@
 package robinhood
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+)
 
 // Account holds the basic account details relevant to the RobinHood API
 type Account struct {
 	Meta
 	AccountNumber              string         `json:"account_number"`
-	BuyingPower                float64        `json:"buying_power,string"`
-	Cash                       float64        `json:"cash,string"`
-	CashAvailableForWithdrawal float64        `json:"cash_available_for_withdrawal,string"`
+	BrokerageAccountType       string         `json:"brokerage_account_type"`
+	BuyingPower                Money          `json:"buying_power"`
+	Cash                       Money          `json:"cash"`
+	CashAvailableForWithdrawal Money          `json:"cash_available_for_withdrawal"`
 	CashBalances               CashBalances   `json:"cash_balances"`
-	CashHeldForOrders          float64        `json:"cash_held_for_orders,string"`
+	CashHeldForOrders          Money          `json:"cash_held_for_orders"`
 	Deactivated                bool           `json:"deactivated"`
 	DepositHalted              bool           `json:"deposit_halted"`
 	MarginBalances             MarginBalances `json:"margin_balances"`
@@ -22,8 +27,8 @@ type Account struct {
 	SmaHeldForOrders           interface{}    `json:"sma_held_for_orders"`
 	SweepEnabled               bool           `json:"sweep_enabled"`
 	Type                       string         `json:"type"`
-	UnclearedDeposits          float64        `json:"uncleared_deposits,string"`
-	UnsettledFunds             float64        `json:"unsettled_funds,string"`
+	UnclearedDeposits          Money          `json:"uncleared_deposits"`
+	UnsettledFunds             Money          `json:"unsettled_funds"`
 	User                       string         `json:"user"`
 	WithdrawalHalted           bool           `json:"withdrawal_halted"`
 }
@@ -31,57 +36,57 @@ type Account struct {
 // UnifiedAccount gives you the whole account info
 type UnifiedAccount struct {
 	AccountBuyingPower struct {
-		Amount       string `json:"amount,float64"`
+		Amount       Money  `json:"amount"`
 		CurrencyCode string `json:"currency_code"`
 		CurrencyID   string `json:"currency_id"`
 	} `json:"account_buying_power"`
 	CashAvailableFromInstantDeposits struct {
-		Amount       string `json:"amount,float64"`
+		Amount       Money  `json:"amount"`
 		CurrencyCode string `json:"currency_code"`
 		CurrencyID   string `json:"currency_id"`
 	} `json:"cash_available_from_instant_deposits"`
 	CashHeldForCurrencyOrders struct {
-		Amount       string `json:"amount,float64"`
+		Amount       Money  `json:"amount"`
 		CurrencyCode string `json:"currency_code"`
 		CurrencyID   string `json:"currency_id"`
 	} `json:"cash_held_for_currency_orders"`
 	CashHeldForDividends struct {
-		Amount       string `json:"amount,float64"`
+		Amount       Money  `json:"amount"`
 		CurrencyCode string `json:"currency_code"`
 		CurrencyID   string `json:"currency_id"`
 	} `json:"cash_held_for_dividends"`
 	CashHeldForEquityOrders struct {
-		Amount       string `json:"amount,float64"`
+		Amount       Money  `json:"amount"`
 		CurrencyCode string `json:"currency_code"`
 		CurrencyID   string `json:"currency_id"`
 	} `json:"cash_held_for_equity_orders"`
 	CashHeldForOptionsCollateral struct {
-		Amount       string `json:"amount,float64"`
+		Amount       Money  `json:"amount"`
 		CurrencyCode string `json:"currency_code"`
 		CurrencyID   string `json:"currency_id"`
 	} `json:"cash_held_for_options_collateral"`
 	CashHeldForOrders struct {
-		Amount       string `json:"amount,float64"`
+		Amount       Money  `json:"amount"`
 		CurrencyCode string `json:"currency_code"`
 		CurrencyID   string `json:"currency_id"`
 	} `json:"cash_held_for_orders"`
 	CryptoBuyingPower struct {
-		Amount       string `json:"amount,float64"`
+		Amount       Money  `json:"amount"`
 		CurrencyCode string `json:"currency_code"`
 		CurrencyID   string `json:"currency_id"`
 	} `json:"crypto_buying_power"`
 	PortfolioEquity struct {
-		Amount       string `json:"amount,float64"`
+		Amount       Money  `json:"amount"`
 		CurrencyCode string `json:"currency_code"`
 		CurrencyID   string `json:"currency_id"`
 	} `json:"portfolio_equity"`
 	UninvestedCash struct {
-		Amount       string `json:"amount,float64"`
+		Amount       Money  `json:"amount"`
 		CurrencyCode string `json:"currency_code"`
 		CurrencyID   string `json:"currency_id"`
 	} `json:"uninvested_cash"`
 	WithdrawableCash struct {
-		Amount       string `json:"amount,float64"`
+		Amount       Money  `json:"amount"`
 		CurrencyCode string `json:"currency_code"`
 		CurrencyID   string `json:"currency_id"`
 	} `json:"withdrawable_cash"`
@@ -90,41 +95,206 @@ type UnifiedAccount struct {
 // CashBalances reflect the amount of cash available
 type CashBalances struct {
 	Meta
-	BuyingPower                float64 `json:"buying_power,string"`
-	Cash                       float64 `json:"cash,string"`
-	CashAvailableForWithdrawal float64 `json:"cash_available_for_withdrawal,string"`
-	CashHeldForOrders          float64 `json:"cash_held_for_orders,string"`
-	UnclearedDeposits          float64 `json:"uncleared_deposits,string"`
-	UnsettledFunds             float64 `json:"unsettled_funds,string"`
+	BuyingPower                Money `json:"buying_power"`
+	Cash                       Money `json:"cash"`
+	CashAvailableForWithdrawal Money `json:"cash_available_for_withdrawal"`
+	CashHeldForOrders          Money `json:"cash_held_for_orders"`
+	UnclearedDeposits          Money `json:"uncleared_deposits"`
+	UnsettledFunds             Money `json:"unsettled_funds"`
 }
 
 // MarginBalances reflect the balance available in margin accounts
 type MarginBalances struct {
 	Meta
-	Cash                              float64 `json:"cash,string"`
-	CashAvailableForWithdrawal        float64 `json:"cash_available_for_withdrawal,string"`
-	CashHeldForOrders                 float64 `json:"cash_held_for_orders,string"`
-	DayTradeBuyingPower               float64 `json:"day_trade_buying_power,string"`
-	DayTradeBuyingPowerHeldForOrders  float64 `json:"day_trade_buying_power_held_for_orders,string"`
+	Cash                              Money   `json:"cash"`
+	CashAvailableForWithdrawal        Money   `json:"cash_available_for_withdrawal"`
+	CashHeldForOrders                 Money   `json:"cash_held_for_orders"`
+	DayTradeBuyingPower               Money   `json:"day_trade_buying_power"`
+	DayTradeBuyingPowerHeldForOrders  Money   `json:"day_trade_buying_power_held_for_orders"`
 	DayTradeRatio                     float64 `json:"day_trade_ratio,string"`
-	MarginLimit                       float64 `json:"margin_limit,string"`
-	MarkedPatternDayTraderDate        string  `json:"marked_pattern_day_trader_date"`
-	OvernightBuyingPower              float64 `json:"overnight_buying_power,string"`
-	OvernightBuyingPowerHeldForOrders float64 `json:"overnight_buying_power_held_for_orders,string"`
+	MarginLimit                       Money   `json:"margin_limit"`
+	MarkedPatternDayTraderDate        Date    `json:"marked_pattern_day_trader_date"`
+	OvernightBuyingPower              Money   `json:"overnight_buying_power"`
+	OvernightBuyingPowerHeldForOrders Money   `json:"overnight_buying_power_held_for_orders"`
 	OvernightRatio                    float64 `json:"overnight_ratio,string"`
-	UnallocatedMarginCash             float64 `json:"unallocated_margin_cash,string"`
-	UnclearedDeposits                 float64 `json:"uncleared_deposits,string"`
-	UnsettledFunds                    float64 `json:"unsettled_funds,string"`
+	UnallocatedMarginCash             Money   `json:"unallocated_margin_cash"`
+	UnclearedDeposits                 Money   `json:"uncleared_deposits"`
+	UnsettledFunds                    Money   `json:"unsettled_funds"`
 }
 
 // GetAccounts returns all the accounts associated with a login/client.
 func (c *Client) GetAccounts(ctx context.Context) ([]Account, error) {
-	var r struct{ Results []Account }
-	err := c.GetAndDecode(ctx, EPAccounts, &r)
+	return c.IterAccounts().All(ctx)
+}
+
+// Errors returned when the Client has no account to act on.
+var (
+	// ErrAccountNotFound is returned when no account matches the account
+	// selected with WithAccountNumber, WithAccountType or UseAccount.
+	ErrAccountNotFound = errors.New("no matching account")
+	// ErrNoAccount is returned by calls that need a brokerage account when
+	// the login has none.
+	ErrNoAccount = errors.New("no brokerage account")
+	// ErrNoCryptoAccount is returned by calls that need a crypto account when
+	// the login has none.
+	ErrNoCryptoAccount = errors.New("no crypto account")
+)
+
+// An accountSelector picks one of the brokerage accounts of a login.
+type accountSelector struct {
+	number, typ string
+}
+
+func (s accountSelector) match(a Account) bool {
+	switch {
+	case s.number != "":
+		return a.AccountNumber == s.number
+	case s.typ != "":
+		return a.Type == s.typ || a.BrokerageAccountType == s.typ
+	}
+	return true
+}
+
+func (s accountSelector) String() string {
+	if s.number != "" {
+		return "account number " + s.number
+	}
+	return "account type " + s.typ
+}
+
+// WithAccountNumber makes the Client use the brokerage account with the given
+// number, rather than the first account of the login.
+func WithAccountNumber(number string) DialOption {
+	return func(c *Client) {
+		c.accountSel = accountSelector{number: number}
+	}
+}
+
+// WithAccountType makes the Client use the first brokerage account of the
+// given type, rather than the first account of the login. The type is matched
+// against both Type ("cash" or "margin") and BrokerageAccountType (e.g.
+// "individual" or "ira_roth").
+func WithAccountType(typ string) DialOption {
+	return func(c *Client) {
+		c.accountSel = accountSelector{typ: typ}
+	}
+}
+
+// selectAccount returns the account chosen by sel from as, or nil if as is
+// empty and no particular account was asked for.
+func selectAccount(as []Account, sel accountSelector) (*Account, error) {
+	for i := range as {
+		if sel.match(as[i]) {
+			return &as[i], nil
+		}
+	}
+	if sel == (accountSelector{}) {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, sel)
+}
+
+// WithLazyAccounts makes Dial perform no network I/O at all. The brokerage
+// and crypto accounts are instead looked up the first time a call needs them,
+// and Client.Account and Client.CryptoAccount stay nil until then.
+func WithLazyAccounts() DialOption {
+	return func(c *Client) {
+		c.lazyAccounts = true
+	}
+}
+
+// UseAccount switches the Client to the brokerage account with the given
+// number, which is used by every later call that does not override it.
+func (c *Client) UseAccount(ctx context.Context, number string) error {
+	as, err := c.GetAccounts(ctx)
+	if err != nil {
+		return err
+	}
+	a, err := selectAccount(as, accountSelector{number: number})
+	if err != nil {
+		return err
+	}
+
+	c.acctMu.Lock()
+	defer c.acctMu.Unlock()
+	c.Account = a
+	return nil
+}
+
+// ActiveAccount returns the brokerage account the Client acts on, looking it
+// up on first use. It returns ErrNoAccount if the login has no brokerage
+// account. A failed lookup is retried by the next call.
+func (c *Client) ActiveAccount(ctx context.Context) (*Account, error) {
+	c.acctMu.Lock()
+	defer c.acctMu.Unlock()
+
+	if c.Account != nil {
+		return c.Account, nil
+	}
+	if c.acctResolved {
+		return nil, ErrNoAccount
+	}
+
+	as, err := c.GetAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get accounts: %w", err)
+	}
+	a, err := selectAccount(as, c.accountSel)
 	if err != nil {
 		return nil, err
 	}
-	return r.Results, err
+	c.acctResolved = true
+	if a == nil {
+		return nil, ErrNoAccount
+	}
+	c.Account = a
+	return a, nil
+}
+
+// ActiveCryptoAccount returns the crypto account the Client acts on, looking
+// it up on first use. It returns ErrNoCryptoAccount if the login has no crypto
+// account. A failed lookup is retried by the next call.
+func (c *Client) ActiveCryptoAccount(ctx context.Context) (*CryptoAccount, error) {
+	c.acctMu.Lock()
+	defer c.acctMu.Unlock()
+
+	if c.CryptoAccount != nil {
+		return c.CryptoAccount, nil
+	}
+	if c.cryptoResolved {
+		return nil, ErrNoCryptoAccount
+	}
+
+	ca, err := c.GetCryptoAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get crypto accounts: %w", err)
+	}
+	c.cryptoResolved = true
+	if len(ca) == 0 {
+		return nil, ErrNoCryptoAccount
+	}
+	c.CryptoAccount = &ca[0]
+	return c.CryptoAccount, nil
+}
+
+// resolveAccounts looks up both accounts, tolerating a login that lacks
+// either.
+func (c *Client) resolveAccounts(ctx context.Context) error {
+	if _, err := c.ActiveAccount(ctx); err != nil && !errors.Is(err, ErrNoAccount) {
+		return err
+	}
+	if _, err := c.ActiveCryptoAccount(ctx); err != nil && !errors.Is(err, ErrNoCryptoAccount) {
+		return err
+	}
+	return nil
+}
+
+// accountFor returns override if it is set, or else the Client's account.
+func (c *Client) accountFor(ctx context.Context, override *Account) (*Account, error) {
+	if override != nil {
+		return override, nil
+	}
+	return c.ActiveAccount(ctx)
 }
 
 // CryptoAccount holds the basic account details relevant to robinhood API
@@ -136,19 +306,13 @@ type CryptoAccount struct {
 
 // GetCryptoAccounts will return associated cryto account
 func (c *Client) GetCryptoAccounts(ctx context.Context) ([]CryptoAccount, error) {
-	var r struct{ Results []CryptoAccount }
-	err := c.GetAndDecode(ctx, EPCryptoAccount, &r)
-	if err != nil {
-		return nil, err
-	}
-
-	return r.Results, err
+	return c.IterCryptoAccounts().All(ctx)
 }
 
 // GetUnifiedAccount will return account information we can use
 func (c *Client) GetUnifiedAccount(ctx context.Context) (*UnifiedAccount, error) {
 	var r UnifiedAccount
-	url := PhoenixEPBase + PhoenixEPAccountUnified
+	url := c.ep().AccountUnified
 	err := c.GetAndDecode(ctx, url, &r)
 
 	if err != nil {
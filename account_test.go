@@ -0,0 +1,149 @@
+package robinhood_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"astuart.co/go-robinhood/v2"
+	"astuart.co/go-robinhood/v2/robinhoodtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialSelectsAccount(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	cash := s.AddAccount(robinhood.Account{Type: "cash", BuyingPower: robinhood.MustMoney("500"), Cash: robinhood.MustMoney("500")})
+	ira := s.AddAccount(robinhood.Account{Type: "cash", BrokerageAccountType: "ira_roth"})
+
+	ctx := context.Background()
+
+	c, err := s.Dial(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "margin", c.Account.Type)
+
+	c, err = s.Dial(ctx, robinhood.WithAccountNumber(ira.AccountNumber))
+	require.NoError(t, err)
+	assert.Equal(t, ira.URL, c.Account.URL)
+
+	c, err = s.Dial(ctx, robinhood.WithAccountType("cash"))
+	require.NoError(t, err)
+	assert.Equal(t, cash.URL, c.Account.URL)
+
+	c, err = s.Dial(ctx, robinhood.WithAccountType("ira_roth"))
+	require.NoError(t, err)
+	assert.Equal(t, ira.URL, c.Account.URL)
+
+	_, err = s.Dial(ctx, robinhood.WithAccountNumber("NOPE"))
+	assert.True(t, errors.Is(err, robinhood.ErrAccountNotFound), "%v", err)
+
+	require.NoError(t, c.UseAccount(ctx, cash.AccountNumber))
+	assert.Equal(t, cash.URL, c.Account.URL)
+	err = c.UseAccount(ctx, "NOPE")
+	assert.True(t, errors.Is(err, robinhood.ErrAccountNotFound), "%v", err)
+	assert.Equal(t, cash.URL, c.Account.URL)
+}
+
+func TestDialReportsAccountErrors(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.FailRequests(1, http.StatusForbidden, "/api/accounts/")
+
+	_, err := s.Dial(context.Background())
+	var apiErr *robinhood.APIError
+	if assert.True(t, errors.As(err, &apiErr), "%v", err) {
+		assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	}
+}
+
+func TestPerCallAccount(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	spy := s.AddStock("SPY", robinhood.MustMoney("100"))
+	cash := s.AddAccount(robinhood.Account{Type: "cash", BuyingPower: robinhood.MustMoney("1000"), Cash: robinhood.MustMoney("1000")})
+	s.SetPosition(spy, robinhood.QuantityFromInt(1), robinhood.MustMoney("90"))
+	require.NoError(t, s.SetAccountPosition(cash.AccountNumber, spy, robinhood.QuantityFromInt(3), robinhood.MustMoney("80")))
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx)
+	require.NoError(t, err)
+
+	ps, err := c.GetPositionsParams(ctx, robinhood.PositionParams{Account: &cash})
+	require.NoError(t, err)
+	if assert.Len(t, ps, 1) {
+		assert.Equal(t, cash.URL, ps[0].Account)
+		assert.Equal(t, "3", ps[0].Quantity.String())
+	}
+
+	ps, err = c.GetPositions(ctx)
+	require.NoError(t, err)
+	assert.Len(t, ps, 2)
+
+	o, err := c.Order(ctx, &spy, robinhood.OrderOpts{Side: robinhood.Sell, Type: robinhood.Market, Quantity: robinhood.QuantityFromInt(2), Account: &cash})
+	require.NoError(t, err)
+	require.NoError(t, o.Update(ctx))
+	require.NoError(t, o.Update(ctx))
+	assert.Equal(t, robinhoodtest.StateFilled, o.State)
+
+	a, _ := s.Account(cash.AccountNumber)
+	assert.Equal(t, "1200", a.Cash.String())
+	a, _ = s.Account(c.Account.AccountNumber)
+	assert.Equal(t, "10000", a.Cash.String())
+}
+
+func TestDialLazyAccounts(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	spy := s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithLazyAccounts())
+	require.NoError(t, err)
+	assert.Empty(t, s.RequestLog())
+	assert.Nil(t, c.Account)
+	assert.Nil(t, c.CryptoAccount)
+
+	// A failed lookup is not remembered.
+	s.FailRequests(1, http.StatusInternalServerError, "/api/accounts/")
+	_, err = c.Order(ctx, &spy, robinhood.OrderOpts{Side: robinhood.Buy, Type: robinhood.Market, Quantity: robinhood.QuantityFromInt(1)})
+	assert.Error(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Order(ctx, &spy, robinhood.OrderOpts{Side: robinhood.Buy, Type: robinhood.Market, Quantity: robinhood.QuantityFromInt(1)})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 2, countRequests(s, "GET /api/accounts/"))
+	require.NotNil(t, c.Account)
+	assert.Equal(t, "margin", c.Account.Type)
+}
+
+func TestNoAccounts(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	spy := s.AddStock("SPY", robinhood.MustMoney("100"))
+	s.ClearAccounts()
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx)
+	require.NoError(t, err)
+	n := len(s.RequestLog())
+
+	_, err = c.Order(ctx, &spy, robinhood.OrderOpts{Side: robinhood.Buy, Type: robinhood.Market, Quantity: robinhood.QuantityFromInt(1)})
+	assert.True(t, errors.Is(err, robinhood.ErrNoAccount), "%v", err)
+	_, err = c.GetCryptoPortfolios(ctx)
+	assert.True(t, errors.Is(err, robinhood.ErrNoCryptoAccount), "%v", err)
+	_, err = c.CryptoOrder(ctx, robinhood.CryptoCurrencyPair{}, robinhood.CryptoOrderOpts{})
+	assert.True(t, errors.Is(err, robinhood.ErrNoCryptoAccount), "%v", err)
+
+	// The absence of accounts is remembered.
+	assert.Equal(t, n, len(s.RequestLog()))
+}
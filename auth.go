@@ -0,0 +1,156 @@
+package robinhood
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// An Invalidator is a TokenSource that caches its tokens, such as a
+// CredsCacher. When the API rejects a token, the Client calls Invalidate so
+// that the next call to Token obtains a fresh one instead of returning the
+// cached token again.
+type Invalidator interface {
+	Invalidate() error
+}
+
+// A ReauthFunc is called when the Client needs a new token but its
+// TokenSource cannot provide one, for example because the login now requires
+// an MFA code (ErrMFARequired). It may interact with the user and return a
+// TokenSource to log in with instead, such as an OAuth with MFA set, which
+// replaces the Client's TokenSource. Returning an error gives up, and the
+// error is returned to the caller.
+type ReauthFunc func(ctx context.Context, cause error) (oauth2.TokenSource, error)
+
+// WithReauthHandler sets the function called when re-authentication cannot
+// be done non-interactively.
+func WithReauthHandler(f ReauthFunc) DialOption {
+	return func(c *Client) {
+		c.onReauth = f
+	}
+}
+
+// authSource caches the token of an underlying TokenSource, like
+// oauth2.ReuseTokenSource, but can be told to discard a rejected token.
+type authSource struct {
+	onReauth ReauthFunc
+
+	mu  sync.Mutex
+	src oauth2.TokenSource
+	tok *oauth2.Token
+}
+
+// Token implements oauth2.TokenSource.
+func (s *authSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tok.Valid() {
+		return s.tok, nil
+	}
+	tok, err := s.fetch(context.Background())
+	if err != nil {
+		return nil, &tokenError{err}
+	}
+	return tok, nil
+}
+
+// A tokenError is a failure to obtain a token in the client's transport. It
+// marks the failure as not the fault of the host the request was for.
+type tokenError struct {
+	err error
+}
+
+func (e *tokenError) Error() string { return e.err.Error() }
+
+// Unwrap returns the error from the TokenSource.
+func (e *tokenError) Unwrap() error { return e.err }
+
+// reauthenticate discards the rejected token used and obtains a new one. If
+// another call has already replaced used, there is nothing to do.
+func (s *authSource) reauthenticate(ctx context.Context, used *oauth2.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if used != nil && s.tok != nil && s.tok != used {
+		return nil
+	}
+
+	s.tok = nil
+	if inv, ok := s.src.(Invalidator); ok {
+		if err := inv.Invalidate(); err != nil {
+			return fmt.Errorf("could not invalidate token: %w", err)
+		}
+	}
+
+	if _, err := s.fetch(ctx); err != nil {
+		return fmt.Errorf("could not reauthenticate: %w", err)
+	}
+	return nil
+}
+
+// revoke revokes the cached token with the source, or with r if the source is
+// not a Revoker, and discards it.
+func (s *authSource) revoke(ctx context.Context, r Revoker) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sr, ok := s.src.(Revoker); ok {
+		r = sr
+	}
+	if err := r.Revoke(ctx, s.tok); err != nil {
+		return err
+	}
+	s.tok = nil
+	return nil
+}
+
+// A ContextTokenSource is a TokenSource that can obtain a token with a
+// context, such as an OAuth or a CredsCacher. The client uses it so that
+// logging in again is cancelled with the request that needed it.
+type ContextTokenSource interface {
+	oauth2.TokenSource
+	TokenContext(ctx context.Context) (*oauth2.Token, error)
+}
+
+// tokenContext obtains a token from src with ctx if src supports it.
+func tokenContext(ctx context.Context, src oauth2.TokenSource) (*oauth2.Token, error) {
+	if cs, ok := src.(ContextTokenSource); ok {
+		return cs.TokenContext(ctx)
+	}
+	return src.Token()
+}
+
+// fetch obtains a new token, falling back to onReauth if the source fails.
+// s.mu must be held.
+func (s *authSource) fetch(ctx context.Context) (*oauth2.Token, error) {
+	tok, err := tokenContext(ctx, s.src)
+	if err != nil && s.onReauth != nil {
+		src, herr := s.onReauth(ctx, err)
+		if herr != nil {
+			return nil, herr
+		}
+		s.src = src
+		tok, err = tokenContext(ctx, src)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.tok = tok
+	return tok, nil
+}
+
+// Logout revokes the client's access and refresh tokens with the Robinhood
+// API and discards them, along with any token cached by the client's
+// TokenSource, such as a CredsCacher. Later requests log in again if the
+// TokenSource can.
+func (c *Client) Logout(ctx context.Context) error {
+	if c.auth == nil {
+		return nil
+	}
+	ep := c.ep()
+	return c.auth.revoke(ctx, &OAuth{Endpoints: &ep, HTTPClient: c.httpClient})
+}
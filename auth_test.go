@@ -0,0 +1,440 @@
+package robinhood_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"astuart.co/go-robinhood/v2"
+	"astuart.co/go-robinhood/v2/robinhoodtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestReauthOnRevokedToken(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	spy := s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	ep := s.Endpoints()
+	path := filepath.Join(t.TempDir(), "robinhood.token")
+	cc := &robinhood.CredsCacher{
+		Creds: &robinhood.OAuth{Username: "user", Password: "password", Endpoints: &ep},
+		Path:  path,
+	}
+
+	ctx := context.Background()
+	c, err := robinhood.Dial(ctx, cc, robinhood.WithEndpoints(ep))
+	require.NoError(t, err)
+	old := readToken(t, path)
+
+	// The cached token still looks valid locally after it is revoked.
+	s.RevokeTokens()
+
+	o, err := c.Order(ctx, &spy, robinhood.OrderOpts{Side: robinhood.Buy, Type: robinhood.Market, Quantity: robinhood.QuantityFromInt(1)})
+	require.NoError(t, err)
+	assert.Equal(t, robinhoodtest.StateUnconfirmed, o.State)
+	os, err := c.AllOrders(ctx)
+	require.NoError(t, err)
+	assert.Len(t, os, 1)
+
+	assert.NotEqual(t, old.AccessToken, readToken(t, path).AccessToken)
+}
+
+func TestReauthRetriesOnce(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	var n int32
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithMiddleware(slowCalls(0, &n)))
+	require.NoError(t, err)
+	atomic.StoreInt32(&n, 0)
+
+	// The server's own token source keeps returning the revoked token.
+	s.RevokeTokens()
+	_, err = c.GetQuote(ctx, "SPY")
+	assert.True(t, errors.Is(err, robinhood.ErrUnauthorized), "%v", err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&n))
+}
+
+func TestReauthHandler(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	ep := s.Endpoints()
+	var causes []error
+	handler := func(ctx context.Context, cause error) (oauth2.TokenSource, error) {
+		causes = append(causes, cause)
+		return &robinhood.OAuth{Username: "user", Password: "password", MFA: "654321", Endpoints: &ep}, nil
+	}
+
+	ctx := context.Background()
+	c, err := robinhood.Dial(ctx, &robinhood.OAuth{Username: "user", Password: "password", Endpoints: &ep},
+		robinhood.WithEndpoints(ep), robinhood.WithReauthHandler(handler))
+	require.NoError(t, err)
+	assert.Empty(t, causes)
+
+	s.SetCredentials("user", "password", "654321")
+	s.RevokeTokens()
+
+	_, err = c.GetQuote(ctx, "SPY")
+	assert.NoError(t, err)
+	if assert.Len(t, causes, 1) {
+		assert.True(t, errors.Is(causes[0], robinhood.ErrMFARequired), "%v", causes[0])
+	}
+}
+
+func readToken(t *testing.T, path string) oauth2.Token {
+	bs, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	var tok oauth2.Token
+	require.NoError(t, json.Unmarshal(bs, &tok))
+	return tok
+}
+
+func TestOAuthRefreshGrant(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.SetCredentials("user", "password", "123456")
+
+	ep := s.Endpoints()
+	challenges := 0
+	o := &robinhood.OAuth{Username: "user", Password: "password", Endpoints: &ep,
+		OnChallenge: func(*robinhood.MFAChallenge) (string, error) {
+			challenges++
+			return "123456", nil
+		},
+	}
+
+	tok, err := o.Token()
+	require.NoError(t, err)
+	assert.NotEmpty(t, tok.RefreshToken)
+	assert.Equal(t, tok.RefreshToken, o.RefreshToken)
+
+	tok2, err := o.Token()
+	require.NoError(t, err)
+	assert.NotEqual(t, tok.AccessToken, tok2.AccessToken)
+	assert.True(t, tok2.Valid())
+	assert.Equal(t, 1, challenges)
+
+	// Once the refresh token is revoked, the password is used again.
+	s.RevokeTokens()
+	_, err = o.Token()
+	require.NoError(t, err)
+	assert.Equal(t, 2, challenges)
+	assert.Equal(t, []string{"password", "refresh_token", "password"}, s.Grants())
+}
+
+func TestCredsCacherRefreshesExpiredToken(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	ep := s.Endpoints()
+	path := filepath.Join(t.TempDir(), "robinhood.token")
+	cc := &robinhood.CredsCacher{
+		Creds: &robinhood.OAuth{Username: "user", Password: "password", Endpoints: &ep},
+		Path:  path,
+	}
+	_, err := cc.Token()
+	require.NoError(t, err)
+
+	// A later run finds the cached token expired.
+	tok := readToken(t, path)
+	tok.Expiry = time.Now().Add(-time.Minute)
+	bs, err := json.Marshal(tok)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, bs, 0600))
+
+	cc = &robinhood.CredsCacher{
+		Creds: &robinhood.OAuth{Username: "user", Password: "wrong", Endpoints: &ep},
+		Path:  path,
+	}
+	tok2, err := cc.Token()
+	require.NoError(t, err)
+	assert.True(t, tok2.Valid())
+	assert.Equal(t, tok2.AccessToken, readToken(t, path).AccessToken)
+	assert.Equal(t, []string{"password", "refresh_token"}, s.Grants())
+
+	// A rejected token is renewed with the refresh token too.
+	ctx := context.Background()
+	c, err := robinhood.Dial(ctx, cc, robinhood.WithEndpoints(ep))
+	require.NoError(t, err)
+	s.ExpireTokens()
+	_, err = c.GetQuote(ctx, "SPY")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"password", "refresh_token", "refresh_token"}, s.Grants())
+}
+
+type countingTransport struct{ n int32 }
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.n, 1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestOAuthHTTPClientAndEndpoint(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+
+	rt := &countingTransport{}
+	o := &robinhood.OAuth{Username: "user", Password: "password",
+		Endpoint:   s.Endpoints().Login,
+		HTTPClient: &http.Client{Transport: rt},
+	}
+	tok, err := o.Token()
+	require.NoError(t, err)
+	assert.True(t, tok.Valid())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&rt.n))
+	assert.Equal(t, []string{"password"}, s.Grants())
+}
+
+func TestOAuthTokenContext(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+
+	ep := s.Endpoints()
+	o := &robinhood.OAuth{Username: "user", Password: "password", Endpoints: &ep}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := o.TokenContext(ctx)
+	assert.True(t, errors.Is(err, context.Canceled), "%v", err)
+	assert.Empty(t, s.Grants())
+
+	tok, err := o.TokenContext(context.Background())
+	require.NoError(t, err)
+	assert.True(t, tok.Valid())
+}
+
+func TestOAuthLoginErrors(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+
+	ep := s.Endpoints()
+	o := &robinhood.OAuth{Username: "user", Password: "wrong", Endpoints: &ep}
+	_, err := o.Token()
+	assert.True(t, errors.Is(err, robinhood.ErrBadCredentials), "%v", err)
+	var le *robinhood.LoginError
+	if assert.True(t, errors.As(err, &le)) {
+		assert.Equal(t, robinhood.LoginBadCredentials, le.Kind)
+		assert.Equal(t, http.StatusBadRequest, le.Err.StatusCode)
+	}
+	var apiErr *robinhood.APIError
+	assert.True(t, errors.As(err, &apiErr))
+
+	o.Password = "password"
+	s.LockAccount(true)
+	_, err = o.Token()
+	assert.True(t, errors.Is(err, robinhood.ErrAccountLocked), "%v", err)
+	assert.False(t, errors.Is(err, robinhood.ErrBadCredentials))
+	s.LockAccount(false)
+
+	s.ThrottleLogins(1, 30)
+	_, err = o.Token()
+	assert.True(t, errors.Is(err, robinhood.ErrThrottled), "%v", err)
+	if assert.True(t, errors.As(err, &le)) {
+		assert.Equal(t, robinhood.LoginThrottled, le.Kind)
+		assert.Equal(t, 30*time.Second, le.Err.RetryAfter)
+	}
+
+	_, err = o.Token()
+	require.NoError(t, err)
+
+	// A rejected refresh token is a bad credential too.
+	_, err = o.Refresh("not-a-refresh-token")
+	assert.True(t, errors.Is(err, robinhood.ErrBadCredentials), "%v", err)
+}
+
+func TestLogout(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	ep := s.Endpoints()
+	path := filepath.Join(t.TempDir(), "robinhood.token")
+	cc := &robinhood.CredsCacher{
+		Creds: &robinhood.OAuth{Username: "user", Password: "password", Endpoints: &ep},
+		Path:  path,
+	}
+
+	ctx := context.Background()
+	c, err := robinhood.Dial(ctx, cc, robinhood.WithEndpoints(ep))
+	require.NoError(t, err)
+	tok := readToken(t, path)
+	require.True(t, s.TokenActive(tok.AccessToken))
+	require.True(t, s.TokenActive(tok.RefreshToken))
+
+	require.NoError(t, c.Logout(ctx))
+	assert.False(t, s.TokenActive(tok.AccessToken))
+	assert.False(t, s.TokenActive(tok.RefreshToken))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "%v", err)
+
+	// The client logs in again with the password if it is used.
+	_, err = c.GetQuote(ctx, "SPY")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"password", "password"}, s.Grants())
+}
+
+func TestCredsCacherRevokeWithoutRevoker(t *testing.T) {
+	st := &robinhood.MemoryStore{}
+	cc := &robinhood.CredsCacher{
+		Creds: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "static"}),
+		Store: st,
+	}
+	_, err := cc.Token()
+	require.NoError(t, err)
+
+	assert.Error(t, cc.Revoke(context.Background(), nil))
+	tok, err := st.Load()
+	require.NoError(t, err)
+	assert.Nil(t, tok)
+
+	// There is nothing left to revoke.
+	assert.NoError(t, cc.Revoke(context.Background(), nil))
+}
+
+func TestOAuthRevoke(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+
+	o := &robinhood.OAuth{Username: "user", Password: "password", Endpoint: s.Endpoints().Login}
+	tok, err := o.Token()
+	require.NoError(t, err)
+
+	// Without a token, only the remembered refresh token is revoked.
+	require.NoError(t, o.Revoke(context.Background(), nil))
+	assert.False(t, s.TokenActive(tok.RefreshToken))
+	assert.True(t, s.TokenActive(tok.AccessToken))
+	assert.Empty(t, o.RefreshToken)
+
+	require.NoError(t, o.Revoke(context.Background(), tok))
+	assert.False(t, s.TokenActive(tok.AccessToken))
+}
+
+func TestRefreshKeepsTokenOnTransientFailure(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+
+	ep := s.Endpoints()
+	o := &robinhood.OAuth{Username: "user", Password: "password", Endpoints: &ep}
+	tok, err := o.Token()
+	require.NoError(t, err)
+
+	s.ThrottleLogins(1, 0)
+	_, err = o.Token()
+	assert.True(t, errors.Is(err, robinhood.ErrThrottled), "%v", err)
+	assert.Equal(t, tok.RefreshToken, o.RefreshToken)
+
+	_, err = o.Token()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"password", "refresh_token"}, s.Grants())
+
+	// A CredsCacher returns the failure rather than logging in again.
+	path := filepath.Join(t.TempDir(), "robinhood.token")
+	expired := &oauth2.Token{AccessToken: "old", RefreshToken: o.RefreshToken, Expiry: time.Now().Add(-time.Minute)}
+	bs, err := json.Marshal(expired)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, bs, 0600))
+
+	cc := &robinhood.CredsCacher{Creds: &robinhood.OAuth{Username: "user", Password: "password", Endpoints: &ep}, Path: path}
+	s.ThrottleLogins(1, 0)
+	_, err = cc.Token()
+	assert.True(t, errors.Is(err, robinhood.ErrThrottled), "%v", err)
+	assert.Equal(t, expired.RefreshToken, readToken(t, path).RefreshToken)
+
+	_, err = cc.Token()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"password", "refresh_token", "refresh_token"}, s.Grants())
+}
+
+// firstTokenRevoked is a TokenSource whose first token has been revoked.
+type firstTokenRevoked struct {
+	src   oauth2.TokenSource
+	calls int32
+}
+
+func (f *firstTokenRevoked) Token() (*oauth2.Token, error) {
+	if atomic.AddInt32(&f.calls, 1) == 1 {
+		return &oauth2.Token{AccessToken: "revoked"}, nil
+	}
+	return f.src.Token()
+}
+
+func TestReauthOnceForConcurrentFirstCalls(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	ep := s.Endpoints()
+	src := &firstTokenRevoked{src: &robinhood.OAuth{Username: "user", Password: "password", Endpoints: &ep}}
+	ctx := context.Background()
+	c, err := robinhood.Dial(ctx, src, robinhood.WithEndpoints(ep), robinhood.WithLazyAccounts())
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetQuote(ctx, "SPY")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&src.calls))
+}
+
+type ctxKey struct{}
+
+// ctxRecorder records the ctxKey value of each request it sends.
+type ctxRecorder struct {
+	mu   sync.Mutex
+	vals []interface{}
+}
+
+func (c *ctxRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.vals = append(c.vals, req.Context().Value(ctxKey{}))
+	c.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestReauthUsesRequestContext(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	ep := s.Endpoints()
+	rec := &ctxRecorder{}
+	cc := &robinhood.CredsCacher{
+		Creds: &robinhood.OAuth{Username: "user", Password: "password", Endpoints: &ep, HTTPClient: &http.Client{Transport: rec}},
+		Store: &robinhood.MemoryStore{},
+	}
+	c, err := robinhood.Dial(context.Background(), cc, robinhood.WithEndpoints(ep), robinhood.WithLazyAccounts())
+	require.NoError(t, err)
+	_, err = c.GetQuote(context.Background(), "SPY")
+	require.NoError(t, err)
+	s.RevokeTokens()
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+	_, err = c.GetQuote(ctx, "SPY")
+	require.NoError(t, err)
+	require.True(t, len(rec.vals) > 1)
+	assert.Nil(t, rec.vals[0])
+	for _, v := range rec.vals[1:] {
+		assert.Equal(t, "request", v)
+	}
+}
@@ -0,0 +1,192 @@
+package robinhood
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Defaults for BatchOptions.
+const (
+	DefaultBatchSize        = 30
+	DefaultBatchParallelism = 4
+)
+
+// ErrNoResult is reported for an item of a batched request for which the API
+// returned no result, such as an unknown ticker symbol.
+var ErrNoResult = errors.New("no result returned")
+
+// BatchOptions control how endpoints that accept many items at once, such as
+// GetQuote, GetFundamentals and MarketData, split large requests.
+type BatchOptions struct {
+	// Size is the maximum number of items per request. Zero means
+	// DefaultBatchSize.
+	Size int
+	// Parallelism is the maximum number of requests in flight at once. Zero
+	// means DefaultBatchParallelism.
+	Parallelism int
+}
+
+// WithBatchOptions sets how the Client splits multi-item requests.
+func WithBatchOptions(o BatchOptions) DialOption {
+	return func(c *Client) {
+		c.batch = o
+	}
+}
+
+func (o BatchOptions) withDefaults() BatchOptions {
+	if o.Size <= 0 {
+		o.Size = DefaultBatchSize
+	}
+	if o.Parallelism <= 0 {
+		o.Parallelism = DefaultBatchParallelism
+	}
+	return o
+}
+
+// An ItemError is the failure of a single item of a batched request.
+type ItemError struct {
+	// Index is the position of the item in the caller's input.
+	Index int
+	// Item is the input item, e.g. the ticker symbol.
+	Item string
+	Err  error
+}
+
+func (e ItemError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Item, e.Err)
+}
+
+// A BatchError reports the items of a batched request that failed. The
+// results for every other item are still returned alongside it.
+type BatchError struct {
+	Items []ItemError
+	Total int
+}
+
+func (e *BatchError) Error() string {
+	es := make([]string, 0, len(e.Items))
+	for _, ie := range e.Items {
+		es = append(es, ie.Error())
+	}
+	return fmt.Sprintf("%d of %d items failed: %s", len(e.Items), e.Total, strings.Join(es, "; "))
+}
+
+// Unwrap returns the underlying item errors, so errors.Is and errors.As see
+// through a BatchError.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Items))
+	for i, ie := range e.Items {
+		errs[i] = ie.Err
+	}
+	return errs
+}
+
+// batch calls fetch for chunks of items according to the client's
+// BatchOptions. fetch must return one result per item of its chunk, in order,
+// with nil for items that had no result. The results are returned in input
+// order with failed items omitted; a *BatchError lists the items that failed.
+// If the items fit in a single request and that request fails, its error is
+// returned as is.
+func batch[T any](ctx context.Context, c *Client, items []string, fetch func(context.Context, []string) ([]*T, error)) ([]*T, error) {
+	o := c.batch.withDefaults()
+	if len(items) <= o.Size {
+		rs, err := fetch(ctx, items)
+		if err != nil {
+			return nil, err
+		}
+		results := make([]*T, len(items))
+		errs := make([]error, len(items))
+		mapResults(results, errs, rs)
+		return collect(items, results, errs)
+	}
+
+	results := make([]*T, len(items))
+	errs := make([]error, len(items))
+
+	sem := make(chan struct{}, o.Parallelism)
+	var wg sync.WaitGroup
+
+	for start := 0; start < len(items); start += o.Size {
+		end := start + o.Size
+		if end > len(items) {
+			end = len(items)
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			for i := start; i < len(items); i++ {
+				errs[i] = ctx.Err()
+			}
+			start = len(items)
+			continue
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			rs, err := fetch(ctx, items[start:end])
+			if err != nil {
+				for i := start; i < end; i++ {
+					errs[i] = err
+				}
+				return
+			}
+			mapResults(results[start:end], errs[start:end], rs)
+		}(start, end)
+	}
+	wg.Wait()
+
+	return collect(items, results, errs)
+}
+
+// mapResults copies the results of one request into results, recording an
+// error in errs for each item without one.
+func mapResults[T any](results []*T, errs []error, rs []*T) {
+	if len(rs) != len(results) {
+		err := fmt.Errorf("expected %d results, got %d", len(results), len(rs))
+		for i := range errs {
+			errs[i] = err
+		}
+		return
+	}
+	for i, r := range rs {
+		if r == nil {
+			errs[i] = ErrNoResult
+			continue
+		}
+		results[i] = r
+	}
+}
+
+// collect returns the successful results in input order, along with a
+// *BatchError for the items that failed.
+func collect[T any](items []string, results []*T, errs []error) ([]*T, error) {
+	out := make([]*T, 0, len(items))
+	be := &BatchError{Total: len(items)}
+	for i := range items {
+		if errs[i] != nil {
+			be.Items = append(be.Items, ItemError{Index: i, Item: items[i], Err: errs[i]})
+			continue
+		}
+		out = append(out, results[i])
+	}
+
+	if len(be.Items) > 0 {
+		return out, be
+	}
+	return out, nil
+}
+
+func derefAll[T any](ps []*T) []T {
+	out := make([]T, len(ps))
+	for i, p := range ps {
+		out[i] = *p
+	}
+	return out
+}
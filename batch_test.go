@@ -0,0 +1,123 @@
+package robinhood_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"astuart.co/go-robinhood/v2"
+	"astuart.co/go-robinhood/v2/robinhoodtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetQuoteBatches(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.MaxBatch = 10
+
+	var syms []string
+	for i := 0; i < 25; i++ {
+		sym := fmt.Sprintf("S%02d", i)
+		s.AddStock(sym, robinhood.MoneyFromFloat(float64(i+1)))
+		syms = append(syms, sym)
+	}
+	// An unknown symbol in the middle of a batch.
+	syms = append(syms[:12], append([]string{"NOPE"}, syms[12:]...)...)
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithBatchOptions(robinhood.BatchOptions{Size: 10, Parallelism: 2}))
+	require.NoError(t, err)
+
+	qs, err := c.GetQuote(ctx, syms...)
+
+	var be *robinhood.BatchError
+	require.True(t, errors.As(err, &be), "%v", err)
+	assert.Equal(t, 26, be.Total)
+	if assert.Len(t, be.Items, 1) {
+		assert.Equal(t, 12, be.Items[0].Index)
+		assert.Equal(t, "NOPE", be.Items[0].Item)
+	}
+	assert.True(t, errors.Is(err, robinhood.ErrNoResult))
+
+	if assert.Len(t, qs, 25) {
+		for i, q := range qs {
+			assert.Equal(t, fmt.Sprintf("S%02d", i), q.Symbol)
+			assert.Equal(t, strconv.Itoa(i+1), q.LastTradePrice.String())
+		}
+	}
+
+	n := 0
+	for _, l := range s.RequestLog() {
+		if strings.Contains(l, "/quotes/") {
+			n++
+		}
+	}
+	assert.Equal(t, 3, n)
+}
+
+func TestGetFundamentalsChunkFailure(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+
+	syms := []string{"A", "B", "C", "D", "E"}
+	for _, sym := range syms {
+		s.AddStock(sym, robinhood.MustMoney("1"))
+	}
+	s.FailRequests(1, http.StatusBadRequest, "C,D")
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithBatchOptions(robinhood.BatchOptions{Size: 2}))
+	require.NoError(t, err)
+
+	fs, err := c.GetFundamentals(ctx, syms...)
+
+	var be *robinhood.BatchError
+	require.True(t, errors.As(err, &be), "%v", err)
+	if assert.Len(t, be.Items, 2) {
+		assert.Equal(t, "C", be.Items[0].Item)
+		assert.Equal(t, "D", be.Items[1].Item)
+	}
+	var apiErr *robinhood.APIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	}
+
+	if assert.Len(t, fs, 3) {
+		assert.Equal(t, "A", fs[0].Description)
+		assert.Equal(t, "B", fs[1].Description)
+		assert.Equal(t, "E", fs[2].Description)
+	}
+}
+
+func TestMarketDataBatches(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.MaxBatch = robinhood.DefaultBatchSize
+	s.AddStock("SPY", robinhood.MustMoney("300"))
+
+	exp := robinhood.NewDate(2030, 1, 18)
+	var ois []*robinhood.OptionInstrument
+	for i := 0; i < robinhood.DefaultBatchSize+1; i++ {
+		oi, err := s.AddOption("SPY", "call", robinhood.MoneyFromFloat(float64(200+i)), exp, robinhood.MarketData{MarkPrice: robinhood.MoneyFromFloat(float64(i))})
+		require.NoError(t, err)
+		ois = append(ois, &oi)
+	}
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx)
+	require.NoError(t, err)
+
+	md, err := c.MarketData(ctx, ois...)
+	require.NoError(t, err)
+	if assert.Len(t, md, len(ois)) {
+		for i, m := range md {
+			assert.Equal(t, ois[i].URL, m.Instrument)
+			assert.True(t, m.MarkPrice.Equal(robinhood.MoneyFromFloat(float64(i))), "%v", m.MarkPrice)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package robinhood
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Defaults for BreakerOptions.
+const (
+	DefaultBreakerFailures = 5
+	DefaultBreakerCoolDown = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned, without a request being sent, for calls to a
+// host whose circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// A CircuitState is the state of a host's circuit breaker.
+type CircuitState int
+
+// CircuitStates.
+const (
+	// CircuitClosed lets requests through. It is the normal state.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails requests immediately with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of trial requests through after
+	// the cool-down to find out whether the host has recovered.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// BreakerOptions configure the circuit breakers enabled by
+// WithCircuitBreaker. Zero fields take their defaults.
+type BreakerOptions struct {
+	// Failures is the number of consecutive failures, i.e. network errors
+	// and 5xx responses, after which a host's circuit opens. Failures to
+	// obtain a token do not count.
+	Failures int
+	// CoolDown is how long a circuit stays open before trial requests are
+	// let through.
+	CoolDown time.Duration
+	// HalfOpenRequests is the number of concurrent trial requests allowed
+	// while half-open. The default is 1.
+	HalfOpenRequests int
+}
+
+func (o BreakerOptions) withDefaults() BreakerOptions {
+	if o.Failures <= 0 {
+		o.Failures = DefaultBreakerFailures
+	}
+	if o.CoolDown <= 0 {
+		o.CoolDown = DefaultBreakerCoolDown
+	}
+	if o.HalfOpenRequests <= 0 {
+		o.HalfOpenRequests = 1
+	}
+	return o
+}
+
+// WithCircuitBreaker gives each API host its own circuit breaker, so that
+// while one host, such as nummus.robinhood.com, is failing, calls to it fail
+// fast with ErrCircuitOpen instead of piling up, and calls to other hosts are
+// unaffected.
+func WithCircuitBreaker(o BreakerOptions) DialOption {
+	return func(c *Client) {
+		c.breakers = &breakers{opts: o.withDefaults(), hosts: map[string]*breaker{}}
+	}
+}
+
+// CircuitStates returns the state of the circuit breaker of each host the
+// Client has called, for use in health checks. It returns nil unless
+// WithCircuitBreaker was used.
+func (c *Client) CircuitStates() map[string]CircuitState {
+	if c.breakers == nil {
+		return nil
+	}
+
+	c.breakers.mu.Lock()
+	defer c.breakers.mu.Unlock()
+
+	out := make(map[string]CircuitState, len(c.breakers.hosts))
+	for h, b := range c.breakers.hosts {
+		out[h] = b.current()
+	}
+	return out
+}
+
+type breakers struct {
+	opts BreakerOptions
+
+	mu    sync.Mutex
+	hosts map[string]*breaker
+}
+
+// get returns the breaker for host, creating it if needed. It is nil-safe.
+func (bs *breakers) get(host string) *breaker {
+	if bs == nil {
+		return nil
+	}
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	b, ok := bs.hosts[host]
+	if !ok {
+		b = &breaker{host: host, opts: bs.opts}
+		bs.hosts[host] = b
+	}
+	return b
+}
+
+// breaker is the circuit breaker of a single host. Its methods are nil-safe.
+type breaker struct {
+	host string
+	opts BreakerOptions
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	trials   int
+}
+
+// current returns the state, taking the end of the cool-down into account.
+func (b *breaker) current() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.opts.CoolDown {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// check returns ErrCircuitOpen if a request would certainly be refused,
+// without reserving a trial request.
+func (b *breaker) check() error {
+	if b != nil && b.current() == CircuitOpen {
+		return b.openErr()
+	}
+	return nil
+}
+
+// allow reports whether a request may be sent, reserving a trial request
+// if half-open. Every successful call must be followed by a call to done.
+func (b *breaker) allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen {
+		if time.Since(b.openedAt) < b.opts.CoolDown {
+			return b.openErr()
+		}
+		b.state = CircuitHalfOpen
+		b.trials = 0
+	}
+	if b.state == CircuitHalfOpen {
+		if b.trials >= b.opts.HalfOpenRequests {
+			return b.openErr()
+		}
+		b.trials++
+	}
+	return nil
+}
+
+// done records the outcome of a request let through by allow.
+func (b *breaker) done(ctx context.Context, res *http.Response, err error) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitHalfOpen {
+		b.trials--
+	}
+
+	switch {
+	case err != nil && (ctx.Err() != nil || !hostFailure(err)):
+		// Cancelled by the caller, or failed before reaching the host, e.g.
+		// because no token could be obtained; says nothing about the host.
+	case err != nil || res.StatusCode >= http.StatusInternalServerError:
+		b.failures++
+		if b.state == CircuitHalfOpen || b.failures >= b.opts.Failures {
+			b.state = CircuitOpen
+			b.openedAt = time.Now()
+		}
+	default:
+		b.state = CircuitClosed
+		b.failures = 0
+	}
+}
+
+// hostFailure reports whether err, returned by the HTTP client, is a failure
+// to reach the host or to get a response from it.
+func hostFailure(err error) bool {
+	var te *tokenError
+	if errors.As(err, &te) {
+		return false
+	}
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		err = ue.Err
+	}
+	return isTransient(err)
+}
+
+func (b *breaker) openErr() error {
+	return fmt.Errorf("%s: %w", b.host, ErrCircuitOpen)
+}
@@ -0,0 +1,128 @@
+package robinhood_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"astuart.co/go-robinhood/v2"
+	"astuart.co/go-robinhood/v2/robinhoodtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+	s.FailRequests(2, http.StatusInternalServerError, "/api/quotes/")
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithCircuitBreaker(robinhood.BreakerOptions{Failures: 2, CoolDown: 50 * time.Millisecond}))
+	require.NoError(t, err)
+	u, _ := url.Parse(s.URL)
+
+	for i := 0; i < 2; i++ {
+		_, err = c.GetQuote(ctx, "SPY")
+		var apiErr *robinhood.APIError
+		assert.True(t, errors.As(err, &apiErr), "%v", err)
+	}
+	assert.Equal(t, robinhood.CircuitOpen, c.CircuitStates()[u.Host])
+
+	n := len(s.RequestLog())
+	_, err = c.GetQuote(ctx, "SPY")
+	assert.True(t, errors.Is(err, robinhood.ErrCircuitOpen), "%v", err)
+	assert.Equal(t, n, len(s.RequestLog()))
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, robinhood.CircuitHalfOpen, c.CircuitStates()[u.Host])
+
+	_, err = c.GetQuote(ctx, "SPY")
+	require.NoError(t, err)
+	assert.Equal(t, robinhood.CircuitClosed, c.CircuitStates()[u.Host])
+}
+
+func TestCircuitBreakerHalfOpenFailure(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+	s.FailRequests(2, http.StatusInternalServerError, "/api/quotes/")
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithCircuitBreaker(robinhood.BreakerOptions{Failures: 1, CoolDown: 50 * time.Millisecond}))
+	require.NoError(t, err)
+	u, _ := url.Parse(s.URL)
+
+	_, err = c.GetQuote(ctx, "SPY")
+	assert.Error(t, err)
+	time.Sleep(60 * time.Millisecond)
+
+	// The trial request fails, so the circuit opens again for a full
+	// cool-down.
+	_, err = c.GetQuote(ctx, "SPY")
+	var apiErr *robinhood.APIError
+	assert.True(t, errors.As(err, &apiErr), "%v", err)
+	assert.Equal(t, robinhood.CircuitOpen, c.CircuitStates()[u.Host])
+	_, err = c.GetQuote(ctx, "SPY")
+	assert.True(t, errors.Is(err, robinhood.ErrCircuitOpen), "%v", err)
+}
+
+func TestCircuitBreakerIsPerHost(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	spy := s.AddStock("SPY", robinhood.MustMoney("100"))
+	s.FailRequests(3, http.StatusInternalServerError, "/nummus/")
+
+	// Serve crypto from the same fake under a different host name.
+	ep := s.Endpoints()
+	ep = robinhood.NewEndpoints(ep.Base, strings.Replace(ep.CryptoBase, "127.0.0.1", "localhost", 1), ep.PhoenixBase)
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx,
+		robinhood.WithEndpoints(ep),
+		robinhood.WithLazyAccounts(),
+		robinhood.WithCircuitBreaker(robinhood.BreakerOptions{Failures: 2, CoolDown: time.Minute}),
+	)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = c.GetCryptoCurrencyPairs(ctx)
+		assert.Error(t, err)
+	}
+	assert.True(t, errors.Is(err, robinhood.ErrCircuitOpen), "%v", err)
+
+	_, err = c.Order(ctx, &spy, robinhood.OrderOpts{Side: robinhood.Buy, Type: robinhood.Market, Quantity: robinhood.QuantityFromInt(1)})
+	require.NoError(t, err)
+
+	u, _ := url.Parse(ep.CryptoBase)
+	states := c.CircuitStates()
+	assert.Equal(t, robinhood.CircuitOpen, states[u.Host])
+	u, _ = url.Parse(ep.Base)
+	assert.Equal(t, robinhood.CircuitClosed, states[u.Host])
+}
+
+func TestCircuitBreakerIgnoresLoginFailures(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	ep := s.Endpoints()
+	ctx := context.Background()
+	c, err := robinhood.Dial(ctx, &robinhood.OAuth{Username: "user", Password: "wrong", Endpoints: &ep},
+		robinhood.WithEndpoints(ep), robinhood.WithLazyAccounts(),
+		robinhood.WithCircuitBreaker(robinhood.BreakerOptions{Failures: 2}))
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		_, err = c.GetQuote(ctx, "SPY")
+		assert.True(t, errors.Is(err, robinhood.ErrBadCredentials), "%v", err)
+		assert.False(t, errors.Is(err, robinhood.ErrCircuitOpen))
+	}
+	for host, st := range c.CircuitStates() {
+		assert.Equal(t, robinhood.CircuitClosed, st, host)
+	}
+}
@@ -0,0 +1,296 @@
+package robinhood
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Resources that may be cached. They are the keys of CacheOptions.TTL.
+const (
+	CacheInstruments   = "instruments"
+	CacheCurrencyPairs = "currency_pairs"
+	CacheOptionChains  = "option_chains"
+)
+
+// Defaults for CacheOptions.
+const (
+	DefaultCacheTTL        = 24 * time.Hour
+	DefaultCacheMaxEntries = 10000
+)
+
+// CacheOptions configure a Cache.
+type CacheOptions struct {
+	// TTL maps a resource, such as CacheInstruments, to how long its entries
+	// stay fresh. Resources not present use DefaultCacheTTL; a negative TTL
+	// disables caching of that resource.
+	TTL map[string]time.Duration
+	// MaxEntries bounds the number of entries held, across all resources. The
+	// least recently used entries are evicted first. Zero means
+	// DefaultCacheMaxEntries.
+	MaxEntries int
+	// Store, if set, persists the cache. Entries are loaded from it by
+	// NewCache and written to it by Cache.Save.
+	Store CacheStore
+}
+
+// A CacheEntry is a single cached value as held by a CacheStore.
+type CacheEntry struct {
+	Resource string          `json:"resource"`
+	Key      string          `json:"key"`
+	Value    json.RawMessage `json:"value"`
+	Expires  time.Time       `json:"expires"`
+}
+
+// A CacheStore persists cache entries between runs.
+type CacheStore interface {
+	Load() ([]CacheEntry, error)
+	Save([]CacheEntry) error
+}
+
+// A Cache holds reference data that rarely changes, such as instruments,
+// crypto currency pairs and option chains, so that it is not refetched on
+// every call. It is safe for concurrent use, and may be shared by several
+// clients. Enable it with WithCache.
+type Cache struct {
+	ttl   map[string]time.Duration
+	max   int
+	store CacheStore
+
+	mu      sync.Mutex
+	lru     *list.List // of *CacheEntry, most recently used first
+	entries map[string]*list.Element
+}
+
+// WithCache makes the Client look up reference data in cache before fetching
+// it from the API.
+func WithCache(cache *Cache) DialOption {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+// NewCache returns a Cache with the given options, loading any entries
+// persisted in o.Store.
+func NewCache(o CacheOptions) (*Cache, error) {
+	if o.MaxEntries <= 0 {
+		o.MaxEntries = DefaultCacheMaxEntries
+	}
+	c := &Cache{
+		ttl:     o.TTL,
+		max:     o.MaxEntries,
+		store:   o.Store,
+		lru:     list.New(),
+		entries: map[string]*list.Element{},
+	}
+
+	if c.store == nil {
+		return c, nil
+	}
+	es, err := c.store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("could not load cache: %w", err)
+	}
+	now := time.Now()
+	for i := range es {
+		if es[i].Expires.After(now) {
+			c.put(es[i])
+		}
+	}
+	return c, nil
+}
+
+func cacheKey(resource, key string) string {
+	return resource + " " + key
+}
+
+func (c *Cache) ttlFor(resource string) time.Duration {
+	if d, ok := c.ttl[resource]; ok {
+		return d
+	}
+	return DefaultCacheTTL
+}
+
+// get decodes the fresh entry for resource and key into dest, reporting
+// whether there was one.
+func (c *Cache) get(resource, key string, dest interface{}) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	el, ok := c.entries[cacheKey(resource, key)]
+	if !ok {
+		c.mu.Unlock()
+		return false
+	}
+	e := el.Value.(*CacheEntry)
+	if !time.Now().Before(e.Expires) {
+		c.remove(el)
+		c.mu.Unlock()
+		return false
+	}
+	c.lru.MoveToFront(el)
+	v := e.Value
+	c.mu.Unlock()
+
+	// Entries are stored encoded so callers never share, and cannot modify,
+	// cached values.
+	return json.Unmarshal(v, dest) == nil
+}
+
+// set caches v for resource and key.
+func (c *Cache) set(resource, key string, v interface{}) {
+	if c == nil {
+		return
+	}
+	ttl := c.ttlFor(resource)
+	if ttl < 0 {
+		return
+	}
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.put(CacheEntry{Resource: resource, Key: key, Value: bs, Expires: time.Now().Add(ttl)})
+}
+
+// put adds e, evicting the least recently used entries if the cache is full.
+// c.mu must be held.
+func (c *Cache) put(e CacheEntry) {
+	k := cacheKey(e.Resource, e.Key)
+	if el, ok := c.entries[k]; ok {
+		el.Value = &e
+		c.lru.MoveToFront(el)
+		return
+	}
+
+	c.entries[k] = c.lru.PushFront(&e)
+	for c.lru.Len() > c.max {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove drops el. c.mu must be held.
+func (c *Cache) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*CacheEntry)
+	delete(c.entries, cacheKey(e.Resource, e.Key))
+}
+
+// Invalidate removes the entry for key of resource. For instruments the key is
+// the instrument URL or symbol.
+func (c *Cache) Invalidate(resource, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[cacheKey(resource, key)]; ok {
+		c.remove(el)
+	}
+}
+
+// InvalidateResource removes every entry of resource.
+func (c *Cache) InvalidateResource(resource string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+		if el.Value.(*CacheEntry).Resource == resource {
+			c.remove(el)
+		}
+		el = next
+	}
+}
+
+// Purge removes every entry.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Init()
+	c.entries = map[string]*list.Element{}
+}
+
+// Len returns the number of entries held, including any that have expired but
+// not yet been evicted.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Save writes the unexpired entries to the cache's Store, if it has one.
+func (c *Cache) Save() error {
+	if c.store == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	now := time.Now()
+	es := make([]CacheEntry, 0, c.lru.Len())
+	for el := c.lru.Back(); el != nil; el = el.Prev() {
+		if e := el.Value.(*CacheEntry); e.Expires.After(now) {
+			es = append(es, *e)
+		}
+	}
+	c.mu.Unlock()
+
+	return c.store.Save(es)
+}
+
+// cached returns the cached value for resource and key if there is one, and
+// otherwise calls fetch and caches its result.
+func cached[T any](c *Client, resource, key string, fetch func() (T, error)) (T, error) {
+	var v T
+	if c.cache.get(resource, key, &v) {
+		return v, nil
+	}
+
+	v, err := fetch()
+	if err != nil {
+		return v, err
+	}
+	c.cache.set(resource, key, v)
+	return v, nil
+}
+
+// A FileCacheStore persists a Cache as JSON in a file.
+type FileCacheStore struct {
+	Path string
+}
+
+// Load implements CacheStore. A missing file holds no entries.
+func (f FileCacheStore) Load() ([]CacheEntry, error) {
+	bs, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var es []CacheEntry
+	if err := json.Unmarshal(bs, &es); err != nil {
+		return nil, fmt.Errorf("could not decode cache file %s: %w", f.Path, err)
+	}
+	return es, nil
+}
+
+// Save implements CacheStore. The file is replaced atomically so a crash
+// never leaves it half written.
+func (f FileCacheStore) Save(es []CacheEntry) error {
+	bs, err := json.Marshal(es)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0750); err != nil {
+		return err
+	}
+	return writeFileAtomic(f.Path, bs, 0600)
+}
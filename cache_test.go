@@ -0,0 +1,147 @@
+package robinhood_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"astuart.co/go-robinhood/v2"
+	"astuart.co/go-robinhood/v2/robinhoodtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countRequests returns the number of logged requests whose URI contains s.
+func countRequests(srv *robinhoodtest.Server, s string) int {
+	n := 0
+	for _, l := range srv.RequestLog() {
+		if strings.Contains(l, s) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestCacheInstruments(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	spy := s.AddStock("SPY", robinhood.MustMoney("300"))
+
+	cache, err := robinhood.NewCache(robinhood.CacheOptions{})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithCache(cache))
+	require.NoError(t, err)
+
+	i, err := c.GetInstrumentForSymbol(ctx, "SPY")
+	require.NoError(t, err)
+	assert.Equal(t, spy.URL, i.URL)
+
+	// Cached by symbol and by URL.
+	i.Name = "changed by caller"
+	i, err = c.GetInstrumentForSymbol(ctx, "SPY")
+	require.NoError(t, err)
+	assert.Equal(t, "SPY", i.Name)
+	_, err = c.GetInstrument(ctx, spy.URL)
+	require.NoError(t, err)
+	assert.Equal(t, 1, countRequests(s, "/instruments/"))
+
+	cache.Invalidate(robinhood.CacheInstruments, "SPY")
+	_, err = c.GetInstrumentForSymbol(ctx, "SPY")
+	require.NoError(t, err)
+	assert.Equal(t, 2, countRequests(s, "/instruments/"))
+
+	cache.InvalidateResource(robinhood.CacheInstruments)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestCacheTTLAndSize(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddCurrencyPair("BTC", robinhood.MustMoney("10000"))
+	for _, sym := range []string{"A", "B", "C"} {
+		s.AddStock(sym, robinhood.MustMoney("1"))
+	}
+
+	cache, err := robinhood.NewCache(robinhood.CacheOptions{
+		TTL:        map[string]time.Duration{robinhood.CacheCurrencyPairs: 20 * time.Millisecond},
+		MaxEntries: 2,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithCache(cache))
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		p, err := c.GetCryptoInstrument(ctx, "BTC")
+		require.NoError(t, err)
+		assert.Equal(t, "BTC", p.CyrptoAssetCurrency.Code)
+	}
+	assert.Equal(t, 1, countRequests(s, "/currency_pairs/"))
+
+	time.Sleep(30 * time.Millisecond)
+	_, err = c.GetCryptoCurrencyPairs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, countRequests(s, "/currency_pairs/"))
+
+	// Each symbol lookup caches two entries, so only the latest survives.
+	for _, sym := range []string{"A", "B", "C"} {
+		_, err := c.GetInstrumentForSymbol(ctx, sym)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, cache.Len())
+	_, err = c.GetInstrumentForSymbol(ctx, "C")
+	require.NoError(t, err)
+	_, err = c.GetInstrumentForSymbol(ctx, "A")
+	require.NoError(t, err)
+	assert.Equal(t, 4, countRequests(s, "/instruments/"))
+}
+
+func TestCacheFileStore(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	spy := s.AddStock("SPY", robinhood.MustMoney("300"))
+	_, err := s.AddOption("SPY", "call", robinhood.MustMoney("300"), robinhood.NewDate(2030, 1, 18), robinhood.MarketData{})
+	require.NoError(t, err)
+
+	store := robinhood.FileCacheStore{Path: filepath.Join(t.TempDir(), "cache", "robinhood.json")}
+	cache, err := robinhood.NewCache(robinhood.CacheOptions{Store: store})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithCache(cache))
+	require.NoError(t, err)
+
+	i, err := c.GetInstrumentForSymbol(ctx, "SPY")
+	require.NoError(t, err)
+	chs, err := c.GetOptionChains(ctx, i)
+	require.NoError(t, err)
+	require.Len(t, chs, 1)
+	require.NoError(t, cache.Save())
+
+	// A new cache, as after a restart, is loaded from the file.
+	cache, err = robinhood.NewCache(robinhood.CacheOptions{Store: store})
+	require.NoError(t, err)
+	c, err = s.Dial(ctx, robinhood.WithCache(cache))
+	require.NoError(t, err)
+
+	i, err = c.GetInstrument(ctx, spy.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "SPY", i.Symbol)
+	chs2, err := c.GetOptionChains(ctx, i)
+	require.NoError(t, err)
+	require.Len(t, chs2, 1)
+	assert.Equal(t, chs[0].ID, chs2[0].ID)
+	assert.Equal(t, chs[0].ExpirationDates, chs2[0].ExpirationDates)
+
+	assert.Equal(t, 1, countRequests(s, "/instruments/"))
+	assert.Equal(t, 1, countRequests(s, "/chains/"))
+
+	// Option instruments are fetched through the chain's client.
+	_, err = chs2[0].GetInstrument(ctx, "call", robinhood.NewDate(2030, 1, 18))
+	assert.NoError(t, err)
+}
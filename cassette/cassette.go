@@ -0,0 +1,321 @@
+// Package cassette records Robinhood API interactions to a file and replays
+// them later without network access, so code that uses a robinhood.Client can
+// be tested deterministically against a real session.
+//
+// A Recorder is an http.RoundTripper that forwards requests to a real
+// transport and remembers each exchange. Credentials, tokens and account
+// numbers are scrubbed before the cassette is written. A Replayer is an
+// http.RoundTripper that serves the saved responses, matching requests on
+// method, path and query. Either may be given to robinhood.Dial with
+// robinhood.WithHTTPClient.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// An Interaction is a single recorded request and its response.
+type Interaction struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	RequestBody  string      `json:"request_body,omitempty"`
+	Status       int         `json:"status"`
+	Header       http.Header `json:"header,omitempty"`
+	ResponseBody string      `json:"response_body"`
+}
+
+// A Cassette is the on-disk form of a recorded session.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// formKeys are login, refresh, revoke and challenge form fields that are
+// replaced in request bodies.
+var formKeys = map[string]bool{
+	"username":      true,
+	"password":      true,
+	"mfa_code":      true,
+	"device_token":  true,
+	"refresh_token": true,
+	"token":         true,
+	"response":      true,
+}
+
+// secretKeys are JSON keys whose values are scrubbed wherever they later
+// appear in a recording, including URLs such as account links.
+var secretKeys = map[string]bool{
+	"access_token":   true,
+	"refresh_token":  true,
+	"device_token":   true,
+	"account_number": true,
+}
+
+// keptHeaders are the only response headers saved to a cassette.
+var keptHeaders = []string{"Content-Type", "Retry-After"}
+
+// A Recorder is an http.RoundTripper that records every exchange it forwards
+// to Transport. Call Save to write the scrubbed cassette.
+type Recorder struct {
+	// Transport performs the real requests. If nil, http.DefaultTransport is
+	// used.
+	Transport http.RoundTripper
+
+	path string
+
+	mu      sync.Mutex
+	ints    []Interaction
+	secrets map[string]string
+}
+
+// NewRecorder returns a Recorder that saves to path.
+func NewRecorder(path string, rt http.RoundTripper) *Recorder {
+	return &Recorder{
+		Transport: rt,
+		path:      path,
+		secrets:   map[string]string{},
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt := r.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	var reqBody []byte
+	if req.Body != nil {
+		bs, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bs
+		req.Body = ioutil.NopCloser(bytes.NewReader(bs))
+	}
+
+	res, err := rt.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+
+	h := http.Header{}
+	for _, k := range keptHeaders {
+		if v := res.Header.Get(k); v != "" {
+			h.Set(k, v)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recBody := scrubForm(req.Header.Get("Content-Type"), reqBody)
+	r.collect(reqBody)
+	r.collect(resBody)
+	r.ints = append(r.ints, Interaction{
+		Method:       req.Method,
+		URL:          req.URL.String(),
+		RequestBody:  string(recBody),
+		Status:       res.StatusCode,
+		Header:       h,
+		ResponseBody: string(resBody),
+	})
+
+	return res, nil
+}
+
+// scrubForm replaces credentials in a form-encoded request body.
+func scrubForm(contentType string, body []byte) []byte {
+	if !strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		return body
+	}
+
+	v, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil
+	}
+	for k := range v {
+		if formKeys[k] {
+			v.Set(k, "redacted-"+strings.Replace(k, "_", "-", -1))
+		}
+	}
+	return []byte(v.Encode())
+}
+
+// collect remembers the values of secret keys in a JSON body so they can be
+// scrubbed from every interaction.
+func (r *Recorder) collect(body []byte) {
+	var v interface{}
+	if len(body) > 0 && json.Unmarshal(body, &v) == nil {
+		r.walk(v)
+	}
+}
+
+func (r *Recorder) walk(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, x := range v {
+			if s, ok := x.(string); ok && secretKeys[k] {
+				r.secret(k, s)
+				continue
+			}
+			r.walk(x)
+		}
+	case []interface{}:
+		for _, x := range v {
+			r.walk(x)
+		}
+	}
+}
+
+func (r *Recorder) secret(kind, s string) {
+	if s == "" {
+		return
+	}
+	if _, ok := r.secrets[s]; !ok {
+		r.secrets[s] = fmt.Sprintf("redacted-%s-%d", strings.Replace(kind, "_", "-", -1), len(r.secrets)+1)
+	}
+}
+
+// Cassette returns the scrubbed interactions recorded so far.
+func (r *Recorder) Cassette() Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Replace longer secrets first so that a secret containing another is
+	// not partially replaced.
+	olds := make([]string, 0, len(r.secrets))
+	for s := range r.secrets {
+		olds = append(olds, s)
+	}
+	sort.Slice(olds, func(i, j int) bool { return len(olds[i]) > len(olds[j]) })
+
+	pairs := make([]string, 0, 2*len(olds))
+	for _, s := range olds {
+		pairs = append(pairs, s, r.secrets[s])
+	}
+	rep := strings.NewReplacer(pairs...)
+
+	c := Cassette{Interactions: make([]Interaction, len(r.ints))}
+	for i, in := range r.ints {
+		in.URL = rep.Replace(in.URL)
+		in.RequestBody = rep.Replace(in.RequestBody)
+		in.ResponseBody = rep.Replace(in.ResponseBody)
+		c.Interactions[i] = in
+	}
+	return c
+}
+
+// Save writes the scrubbed cassette to the recorder's path.
+func (r *Recorder) Save() error {
+	bs, err := json.MarshalIndent(r.Cassette(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, bs, 0600)
+}
+
+// A Replayer is an http.RoundTripper that serves responses from a cassette
+// without making network requests. Requests are matched on method, path and
+// query; when a request was recorded more than once, the recorded responses
+// are served in order.
+type Replayer struct {
+	mu    sync.Mutex
+	queue map[string][]Interaction
+}
+
+// Load reads the cassette at path and returns a Replayer for it.
+func Load(path string) (*Replayer, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Cassette
+	if err := json.Unmarshal(bs, &c); err != nil {
+		return nil, fmt.Errorf("could not decode cassette %s: %w", path, err)
+	}
+	return NewReplayer(c), nil
+}
+
+// NewReplayer returns a Replayer for the given cassette.
+func NewReplayer(c Cassette) *Replayer {
+	r := &Replayer{queue: map[string][]Interaction{}}
+	for _, in := range c.Interactions {
+		u, err := url.Parse(in.URL)
+		if err != nil {
+			continue
+		}
+		k := key(in.Method, u)
+		r.queue[k] = append(r.queue[k], in)
+	}
+	return r
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	k := key(req.Method, req.URL)
+
+	r.mu.Lock()
+	q := r.queue[k]
+	if len(q) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("cassette: no recorded response for %s", k)
+	}
+	in := q[0]
+	r.queue[k] = q[1:]
+	r.mu.Unlock()
+
+	h := http.Header{}
+	for k, v := range in.Header {
+		h[k] = append([]string(nil), v...)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.Status, http.StatusText(in.Status)),
+		StatusCode:    in.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          ioutil.NopCloser(strings.NewReader(in.ResponseBody)),
+		ContentLength: int64(len(in.ResponseBody)),
+		Request:       req,
+	}, nil
+}
+
+// Remaining returns the number of recorded interactions not yet replayed.
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, q := range r.queue {
+		n += len(q)
+	}
+	return n
+}
+
+// key identifies a request by method, path and canonically ordered query.
+func key(method string, u *url.URL) string {
+	return method + " " + u.Path + "?" + u.Query().Encode()
+}
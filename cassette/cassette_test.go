@@ -0,0 +1,153 @@
+package cassette_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"astuart.co/go-robinhood/v2"
+	"astuart.co/go-robinhood/v2/cassette"
+	"astuart.co/go-robinhood/v2/robinhoodtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+// session exercises the calls that are recorded and then replayed.
+func session(t *testing.T, c *robinhood.Client) {
+	ctx := context.Background()
+
+	i, err := c.GetInstrumentForSymbol(ctx, "SPY")
+	require.NoError(t, err)
+
+	chs, err := c.GetOptionChains(ctx, i)
+	require.NoError(t, err)
+	require.Len(t, chs, 1)
+
+	calls, err := chs[0].GetInstrument(ctx, "call", robinhood.NewDate(2030, 1, 18))
+	require.NoError(t, err)
+
+	md, err := c.MarketData(ctx, calls...)
+	require.NoError(t, err)
+	require.Len(t, md, 1)
+	assert.Equal(t, "4.2", md[0].MarkPrice.String())
+
+	o, err := c.Order(ctx, i, robinhood.OrderOpts{Side: robinhood.Buy, Quantity: robinhood.QuantityFromInt(1)})
+	require.NoError(t, err)
+	require.NoError(t, o.Update(ctx))
+	require.NoError(t, o.Update(ctx))
+	assert.Equal(t, "filled", o.State)
+}
+
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+
+	s := robinhoodtest.NewServer()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+	_, err := s.AddOption("SPY", "call", robinhood.MustMoney("300"), robinhood.NewDate(2030, 1, 18), robinhood.MarketData{MarkPrice: robinhood.MustMoney("4.2")})
+	require.NoError(t, err)
+	ep := s.Endpoints()
+	tok, err := s.TokenSource().Token()
+	require.NoError(t, err)
+
+	rec := cassette.NewRecorder(path, nil)
+	c, err := s.Dial(context.Background(), robinhood.WithHTTPClient(&http.Client{Transport: rec}))
+	require.NoError(t, err)
+	session(t, c)
+	require.NoError(t, rec.Save())
+	s.Close()
+
+	bs, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(bs), "5RY00000")
+	assert.NotContains(t, string(bs), tok.AccessToken)
+
+	rep, err := cassette.Load(path)
+	require.NoError(t, err)
+
+	c, err = robinhood.Dial(context.Background(),
+		oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "replayed"}),
+		robinhood.WithEndpoints(ep),
+		robinhood.WithHTTPClient(&http.Client{Transport: rep}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "redacted-account-number-1", c.Account.AccountNumber)
+	session(t, c)
+	assert.Equal(t, 0, rep.Remaining())
+
+	_, err = c.GetQuote(context.Background(), "SPY")
+	assert.Error(t, err, "unrecorded requests fail")
+}
+
+func TestRecordScrubsLogin(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+
+	rec := cassette.NewRecorder("", nil)
+	hc := &http.Client{Transport: rec}
+
+	res, err := hc.PostForm(s.Endpoints().Login, map[string][]string{
+		"username": {"user"},
+		"password": {"password"},
+	})
+	require.NoError(t, err)
+	res.Body.Close()
+
+	c := rec.Cassette()
+	require.Len(t, c.Interactions, 1)
+	in := c.Interactions[0]
+	assert.Equal(t, "password=redacted-password&username=redacted-username", in.RequestBody)
+	assert.Contains(t, in.ResponseBody, "redacted-access-token-")
+	assert.Contains(t, in.ResponseBody, "redacted-refresh-token-")
+
+	// Refresh tokens sent to renew a login are scrubbed too, even if the
+	// recording never saw them issued.
+	res, err = hc.PostForm(s.Endpoints().Login+"?grant_type=refresh_token", map[string][]string{
+		"refresh_token": {"live-refresh-token"},
+	})
+	require.NoError(t, err)
+	res.Body.Close()
+
+	in = rec.Cassette().Interactions[1]
+	assert.Equal(t, "refresh_token=redacted-refresh-token", in.RequestBody)
+
+	res, err = hc.PostForm(s.Endpoints().Revoke, map[string][]string{
+		"token":           {"live-access-token"},
+		"token_type_hint": {"access_token"},
+	})
+	require.NoError(t, err)
+	res.Body.Close()
+
+	in = rec.Cassette().Interactions[2]
+	assert.Equal(t, "token=redacted-token&token_type_hint=access_token", in.RequestBody)
+
+	// So are the codes sent to answer a login challenge.
+	s.RequireDeviceVerification("sms", "123456")
+	res, err = hc.PostForm(s.Endpoints().Login, map[string][]string{
+		"username":     {"user"},
+		"password":     {"password"},
+		"device_token": {"new-device"},
+	})
+	require.NoError(t, err)
+	var blocked struct {
+		Challenge struct {
+			ID string `json:"id"`
+		} `json:"challenge"`
+	}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&blocked))
+	res.Body.Close()
+	require.NotEmpty(t, blocked.Challenge.ID)
+
+	res, err = hc.PostForm(s.Endpoints().Challenge+blocked.Challenge.ID+"/respond/", map[string][]string{
+		"response": {"123456"},
+	})
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	in = rec.Cassette().Interactions[4]
+	assert.Equal(t, "response=redacted-response", in.RequestBody)
+}
@@ -1,15 +1,17 @@
 package robinhood
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
+	"io/ioutil"
 	"net/http"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/pkg/errors"
 	"golang.org/x/oauth2"
 )
 
@@ -41,43 +43,128 @@ const (
 // A Client is a helpful abstraction around some common metadata required for
 // API operations.
 type Client struct {
-	Token         string
-	Account       *Account
+	Token string
+	// Account is the brokerage account acted on by calls that do not name
+	// one. If nil, it is looked up on first use; see ActiveAccount.
+	Account *Account
+	// CryptoAccount is the crypto account acted on by crypto calls. If nil,
+	// it is looked up on first use; see ActiveCryptoAccount.
 	CryptoAccount *CryptoAccount
+	// Endpoints overrides the API endpoints used by the client. If nil,
+	// DefaultEndpoints is used.
+	Endpoints *Endpoints
 	*http.Client
+
+	limiters        map[string]*tokenBucket
+	throttleRetries int
+	retry           *RetryPolicy
+	middleware      []Middleware
+	httpClient      *http.Client
+	batch           BatchOptions
+	cache           *Cache
+	accountSel      accountSelector
+	lazyAccounts    bool
+	flight          *flights
+	auth            *authSource
+	onReauth        ReauthFunc
+	schema          *schemaChecker
+	breakers        *breakers
+
+	acctMu         sync.Mutex
+	acctResolved   bool
+	cryptoResolved bool
+}
+
+// A DialOption configures a Client during Dial.
+type DialOption func(*Client)
+
+// WithEndpoints makes the Client resolve every API URL from the given endpoint
+// set rather than the production Robinhood hosts.
+func WithEndpoints(e Endpoints) DialOption {
+	return func(c *Client) {
+		c.Endpoints = &e
+	}
+}
+
+// WithHTTPClient makes the Client send requests using a copy of hc, so its
+// Transport, Timeout and other settings apply. Authentication is added on top
+// of hc's Transport.
+func WithHTTPClient(hc *http.Client) DialOption {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
 }
 
 // Dial returns a client given a TokenGetter. TokenGetter implementations are
 // available in this package, including a Cookie-based cache.
-func Dial(ctx context.Context, s oauth2.TokenSource) (*Client, error) {
+func Dial(ctx context.Context, s oauth2.TokenSource, opts ...DialOption) (*Client, error) {
 	c := &Client{
-		Client: oauth2.NewClient(context.Background(), s),
+		throttleRetries: DefaultThrottleRetries,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
 
-	a, err := c.GetAccounts(ctx)
-	if len(a) > 0 {
-		c.Account = &a[0]
+	hc := &http.Client{}
+	if c.httpClient != nil {
+		*hc = *c.httpClient
 	}
+	c.auth = &authSource{src: s, onReauth: c.onReauth}
+	hc.Transport = &oauth2.Transport{
+		Base:   hc.Transport,
+		Source: c.auth,
+	}
+	c.Client = hc
 
-	ca, err := c.GetCryptoAccounts(ctx)
-	if len(ca) > 0 {
-		c.CryptoAccount = &ca[0]
+	if c.lazyAccounts {
+		return c, nil
 	}
+	return c, c.resolveAccounts(ctx)
+}
 
-	return c, err
+// ep returns the endpoint set the client should use.
+func (c *Client) ep() Endpoints {
+	if c.Endpoints != nil {
+		return *c.Endpoints
+	}
+	return DefaultEndpoints
 }
 
 // GetAndDecode retrieves from the endpoint and unmarshals resulting json into
-// the provided destination interface, which must be a pointer.
+// the provided destination interface, which must be a pointer. Failed requests
+// are retried according to the client's RetryPolicy, if any.
 func (c *Client) GetAndDecode(ctx context.Context, url string, dest interface{}) error {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return err
+	if c.flight != nil {
+		return c.coalescedGet(ctx, url, dest)
 	}
+	return c.getAndDecode(ctx, url, dest)
+}
+
+func (c *Client) getAndDecode(ctx context.Context, url string, dest interface{}) error {
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return err
+		}
 
-	return c.DoAndDecode(ctx, req, dest)
+		err = c.doAndRetry(ctx, req, dest, c.retry)
+		if c.retry == nil || !c.retry.shouldRetry(ctx, attempt, err) {
+			return err
+		}
+
+		wait := c.retry.Backoff(attempt)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.hinted && apiErr.RetryAfter > wait {
+			wait = apiErr.RetryAfter
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
 }
 
+const errorMapPrefix = "Error returned from API: "
+
 // ErrorMap encapsulates the helpful error messages returned by the API server
 type ErrorMap map[string]interface{}
 
@@ -86,29 +173,123 @@ func (e ErrorMap) Error() string {
 	for k, v := range e {
 		es = append(es, fmt.Sprintf("%s: %q", k, v))
 	}
-	return "Error returned from API: " + strings.Join(es, ", ")
+	sort.Strings(es)
+	return errorMapPrefix + strings.Join(es, ", ")
 }
 
 // DoAndDecode provides useful abstractions around common errors and decoding
-// issues.
+// issues. Error responses are returned as an *APIError. Requests are subject
+// to any configured rate limits, and idempotent requests that are throttled
+// by the API are retried once the requested wait has passed. If the API
+// rejects the client's token, a new one is obtained and the request is retried
+// once. Calls to a host whose circuit breaker is open fail with
+// ErrCircuitOpen.
 func (c *Client) DoAndDecode(ctx context.Context, req *http.Request, dest interface{}) error {
-	res, err := c.Do(req.WithContext(ctx))
+	return c.doAndRetry(ctx, req, dest, nil)
+}
+
+// doAndRetry implements DoAndDecode. Throttled responses whose status policy
+// retries are returned instead of being retried here, so that they count
+// against the policy's attempts only.
+func (c *Client) doAndRetry(ctx context.Context, req *http.Request, dest interface{}, policy *RetryPolicy) error {
+	reauthed := false
+	for attempt := 0; ; attempt++ {
+		if err := c.breakers.get(req.URL.Host).check(); err != nil {
+			return err
+		}
+
+		err := c.waitForHost(ctx, req.URL.Host)
+		if err != nil {
+			return err
+		}
+
+		// The token is obtained here rather than by the transport, so that a
+		// rejection is attributed to the token that was actually sent.
+		var used *oauth2.Token
+		if c.auth != nil {
+			if used, err = c.auth.Token(); err != nil {
+				return err
+			}
+		}
+
+		err = c.doAndDecode(ctx, req, dest)
+
+		if !reauthed && c.auth != nil && errors.Is(err, ErrUnauthorized) && rewind(req) {
+			reauthed = true
+			if err := c.auth.reauthenticate(ctx, used); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || !apiErr.retryable || !idempotent(req) || attempt >= c.throttleRetries ||
+			policy.retriesStatus(apiErr.StatusCode) {
+			return err
+		}
+
+		if err := sleep(ctx, throttleWait(apiErr, attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+// rewind resets the body of req so that it can be sent again, reporting
+// whether that was possible.
+func rewind(req *http.Request) bool {
+	if req.Body == nil || req.Body == http.NoBody {
+		return true
+	}
+	if req.GetBody == nil {
+		return false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return false
+	}
+	req.Body = body
+	return true
+}
+
+func (c *Client) doAndDecode(ctx context.Context, req *http.Request, dest interface{}) error {
+	call := &Call{
+		Request:  req.WithContext(ctx),
+		Endpoint: c.ep().Name(req.URL.String()),
+		Dest:     dest,
+	}
+	shared, _ := dest.(*sharedBody)
+	if shared != nil {
+		call.Dest = shared.dest
+	}
+	res, err := c.roundTrip(call)
 	if err != nil {
 		return err
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode >= 400 {
-		b := &bytes.Buffer{}
-		var e ErrorMap
-		err = json.NewDecoder(io.TeeReader(res.Body, b)).Decode(&e)
+		bs, err := ioutil.ReadAll(res.Body)
 		if err != nil {
-			return fmt.Errorf("got response %q and could not decode error body %q", res.Status, b.String())
+			return errors.Wrapf(err, "got response %q and could not read error body", res.Status)
 		}
+		e := newAPIError(res, bs)
+		e.RetryAfter, e.hinted = retryAfter(res, e)
+		e.retryable = throttled(res.StatusCode) && e.RetryAfter <= maxRetryAfter
 		return e
 	}
 
-	return json.NewDecoder(res.Body).Decode(dest)
+	if shared != nil {
+		shared.raw, err = ioutil.ReadAll(res.Body)
+		return err
+	}
+	if c.schema == nil {
+		return json.NewDecoder(res.Body).Decode(dest)
+	}
+	bs, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	return c.decode(req.URL.String(), bs, dest)
 }
 
 // Meta holds metadata common to many RobinHood types.
@@ -0,0 +1,229 @@
+package robinhood_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"astuart.co/go-robinhood/v2"
+	"astuart.co/go-robinhood/v2/robinhoodtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDial(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+
+	c, err := s.Dial(context.Background())
+	require.NoError(t, err)
+
+	asrt := assert.New(t)
+	asrt.NotNil(c.Account)
+	asrt.Equal("5RY00000", c.Account.AccountNumber)
+	asrt.NotNil(c.CryptoAccount)
+}
+
+func TestGetQuoteAndInstrument(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("300"))
+	s.AddStock("AAPL", robinhood.MustMoney("150"))
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx)
+	require.NoError(t, err)
+
+	asrt := assert.New(t)
+
+	qs, err := c.GetQuote(ctx, "SPY", "AAPL")
+	asrt.NoError(err)
+	if asrt.Len(qs, 2) {
+		asrt.Equal("SPY", qs[0].Symbol)
+		asrt.Equal("300", qs[0].LastTradePrice.String())
+		asrt.Equal("AAPL", qs[1].Symbol)
+	}
+
+	i, err := c.GetInstrumentForSymbol(ctx, "SPY")
+	asrt.NoError(err)
+	asrt.Equal("SPY", i.Symbol)
+
+	i2, err := c.GetInstrument(ctx, i.URL)
+	asrt.NoError(err)
+	asrt.Equal(i.ID, i2.ID)
+
+	_, err = c.GetInstrumentForSymbol(ctx, "NOPE")
+	asrt.Error(err)
+}
+
+func TestOAuthMFA(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.SetCredentials("alice", "hunter2", "123456")
+
+	ep := s.Endpoints()
+	o := &robinhood.OAuth{Username: "alice", Password: "hunter2", Endpoints: &ep}
+
+	asrt := assert.New(t)
+
+	_, err := o.Token()
+	asrt.True(errors.Is(err, robinhood.ErrMFARequired), "%v", err)
+	var ch *robinhood.MFAChallenge
+	if asrt.True(errors.As(err, &ch)) {
+		asrt.Equal("sms", ch.Type)
+		asrt.Empty(ch.ID)
+	}
+
+	o.MFA = "123456"
+	tok, err := o.Token()
+	asrt.NoError(err)
+	if asrt.NotNil(tok) {
+		asrt.NotEmpty(tok.AccessToken)
+		asrt.True(tok.Valid())
+	}
+
+	c, err := robinhood.Dial(context.Background(), o, robinhood.WithEndpoints(ep))
+	asrt.NoError(err)
+	asrt.NotNil(c.Account)
+}
+
+func TestOAuthMFACallback(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.SetCredentials("alice", "hunter2", "123456")
+
+	ep := s.Endpoints()
+	var seen []string
+	o := &robinhood.OAuth{Username: "alice", Password: "hunter2", Endpoints: &ep,
+		OnChallenge: func(ch *robinhood.MFAChallenge) (string, error) {
+			seen = append(seen, ch.Type)
+			return "123456", nil
+		},
+	}
+
+	tok, err := o.Token()
+	require.NoError(t, err)
+	assert.True(t, tok.Valid())
+	assert.Equal(t, []string{"sms"}, seen)
+}
+
+func TestOAuthDeviceChallenge(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.RequireDeviceVerification("email", "777777")
+
+	ep := s.Endpoints()
+	o := &robinhood.OAuth{Username: "user", Password: "password", Endpoints: &ep, ChallengeType: "email"}
+
+	_, err := o.Token()
+	var ch *robinhood.MFAChallenge
+	require.True(t, errors.As(err, &ch), "%v", err)
+	assert.True(t, errors.Is(err, robinhood.ErrMFARequired))
+	assert.Equal(t, "email", ch.Type)
+	assert.NotEmpty(t, ch.ID)
+	assert.Equal(t, 3, ch.RemainingAttempts)
+	assert.True(t, ch.ExpiresAt.After(time.Now()))
+	require.NotEmpty(t, o.DeviceToken)
+	device := o.DeviceToken
+
+	codes := []string{"000000", "777777"}
+	var attempts []int
+	o.OnChallenge = func(ch *robinhood.MFAChallenge) (string, error) {
+		attempts = append(attempts, ch.RemainingAttempts)
+		code := codes[0]
+		codes = codes[1:]
+		return code, nil
+	}
+	tok, err := o.Token()
+	require.NoError(t, err)
+	assert.True(t, tok.Valid())
+	assert.Equal(t, []int{3, 2}, attempts)
+	assert.Equal(t, device, o.DeviceToken)
+	assert.True(t, s.DeviceVerified(device))
+
+	// A verified device is not challenged again.
+	o.OnChallenge = nil
+	_, err = o.Token()
+	assert.NoError(t, err)
+}
+
+func TestOAuthDeviceChallengeAbandoned(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.RequireDeviceVerification("sms", "777777")
+
+	ep := s.Endpoints()
+	stop := errors.New("no code")
+	o := &robinhood.OAuth{Username: "user", Password: "password", Endpoints: &ep,
+		OnChallenge: func(*robinhood.MFAChallenge) (string, error) { return "", stop },
+	}
+	_, err := o.Token()
+	assert.Equal(t, stop, err)
+
+	n := 0
+	o.OnChallenge = func(*robinhood.MFAChallenge) (string, error) {
+		n++
+		return "000000", nil
+	}
+	_, err = o.Token()
+	var apiErr *robinhood.APIError
+	assert.True(t, errors.As(err, &apiErr), "%v", err)
+	assert.Equal(t, 3, n)
+	assert.False(t, s.DeviceVerified(o.DeviceToken))
+}
+
+func TestOptionsMarketData(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("300"))
+
+	exp := robinhood.NewDate(2030, 1, 18)
+	_, err := s.AddOption("SPY", "call", robinhood.MustMoney("300"), exp, robinhood.MarketData{MarkPrice: robinhood.MustMoney("4.2")})
+	require.NoError(t, err)
+	_, err = s.AddOption("SPY", "call", robinhood.MustMoney("310"), exp, robinhood.MarketData{MarkPrice: robinhood.MustMoney("1.1")})
+	require.NoError(t, err)
+	_, err = s.AddOption("SPY", "put", robinhood.MustMoney("290"), exp, robinhood.MarketData{MarkPrice: robinhood.MustMoney("2.5")})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx)
+	require.NoError(t, err)
+
+	asrt := assert.New(t)
+
+	i, err := c.GetInstrumentForSymbol(ctx, "SPY")
+	require.NoError(t, err)
+
+	chs, err := c.GetOptionChains(ctx, i)
+	require.NoError(t, err)
+	require.Len(t, chs, 1)
+	if asrt.Len(chs[0].ExpirationDates, 1) {
+		asrt.Equal(exp.String(), chs[0].ExpirationDates[0].String())
+	}
+
+	calls, err := chs[0].GetInstrument(ctx, "call", exp)
+	asrt.NoError(err)
+	asrt.Len(calls, 2)
+
+	md, err := c.MarketData(ctx, calls...)
+	asrt.NoError(err)
+	if asrt.Len(md, 2) {
+		asrt.Equal("4.2", md[0].MarkPrice.String())
+		asrt.Equal(calls[0].URL, md[0].Instrument)
+	}
+}
+
+func TestOAuthDeviceChallengeWithEndpoint(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.RequireDeviceVerification("sms", "777777")
+
+	o := &robinhood.OAuth{Username: "user", Password: "password", Endpoint: s.Endpoints().Login,
+		OnChallenge: func(*robinhood.MFAChallenge) (string, error) { return "777777", nil },
+	}
+	tok, err := o.Token()
+	require.NoError(t, err)
+	assert.True(t, tok.Valid())
+	assert.True(t, s.DeviceVerified(o.DeviceToken))
+}
@@ -0,0 +1,106 @@
+package robinhood
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// WithRequestCoalescing makes concurrent GetAndDecode calls for the same URL
+// share a single in-flight HTTP request. Each caller decodes its own copy of
+// the response, so results are never shared between callers.
+//
+// The shared request is not cancelled when a caller's context is; a caller
+// that gives up stops waiting for it, but the request runs to completion for
+// the others. Once every caller has given up, it is cancelled, so a request
+// that hangs is not joined by later callers.
+func WithRequestCoalescing() DialOption {
+	return func(c *Client) {
+		c.flight = &flights{calls: map[string]*flight{}}
+	}
+}
+
+// flights tracks the callers waiting for each shared request.
+type flights struct {
+	group singleflight.Group
+
+	mu    sync.Mutex
+	calls map[string]*flight // by URL
+	seq   int
+}
+
+// A flight is a shared request and the number of callers waiting for it.
+type flight struct {
+	key     string
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+}
+
+// join returns the flight for url, starting one with the values of ctx if
+// there is none.
+func (f *flights) join(ctx context.Context, url string) *flight {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fl, ok := f.calls[url]
+	if !ok {
+		// Each flight has its own key, so a cancelled request that has not
+		// yet returned is never joined.
+		f.seq++
+		fl = &flight{key: url + "#" + strconv.Itoa(f.seq)}
+		fl.ctx, fl.cancel = context.WithCancel(context.WithoutCancel(ctx))
+		f.calls[url] = fl
+	}
+	fl.waiters++
+	return fl
+}
+
+// leave stops waiting for fl, cancelling it if no one else is.
+func (f *flights) leave(url string, fl *flight) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fl.waiters--
+	if fl.waiters > 0 {
+		return
+	}
+	fl.cancel()
+	if f.calls[url] == fl {
+		delete(f.calls, url)
+	}
+}
+
+// coalescedGet fetches url once for all concurrent callers and decodes the
+// shared response body into dest.
+func (c *Client) coalescedGet(ctx context.Context, url string, dest interface{}) error {
+	fl := c.flight.join(ctx, url)
+	defer c.flight.leave(url, fl)
+
+	ch := c.flight.group.DoChan(fl.key, func() (interface{}, error) {
+		body := &sharedBody{dest: dest}
+		err := c.getAndDecode(fl.ctx, url, body)
+		return body.raw, err
+	})
+
+	select {
+	case r := <-ch:
+		if r.Err != nil {
+			return r.Err
+		}
+		return c.decode(url, r.Val.(json.RawMessage), dest)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// A sharedBody is the destination of a shared request. The response body is
+// kept as is for each caller to decode, while middleware sees the Dest of the
+// caller that started the request.
+type sharedBody struct {
+	raw  json.RawMessage
+	dest interface{}
+}
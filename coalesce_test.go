@@ -0,0 +1,157 @@
+package robinhood_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"astuart.co/go-robinhood/v2"
+	"astuart.co/go-robinhood/v2/robinhoodtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowCalls returns a Middleware that delays every call by d and counts them
+// in n.
+func slowCalls(d time.Duration, n *int32) robinhood.Middleware {
+	return func(next robinhood.RoundTripFunc) robinhood.RoundTripFunc {
+		return func(call *robinhood.Call) (*http.Response, error) {
+			atomic.AddInt32(n, 1)
+			time.Sleep(d)
+			return next(call)
+		}
+	}
+}
+
+func TestRequestCoalescing(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	spy := s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	var n int32
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithRequestCoalescing(), robinhood.WithMiddleware(slowCalls(50*time.Millisecond, &n)))
+	require.NoError(t, err)
+	atomic.StoreInt32(&n, 0)
+
+	insts := make([]*robinhood.Instrument, 8)
+	var wg sync.WaitGroup
+	for i := range insts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			inst, err := c.GetInstrument(ctx, spy.URL)
+			assert.NoError(t, err)
+			insts[i] = inst
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&n))
+
+	// Every caller has its own copy.
+	insts[0].Name = "changed"
+	for _, inst := range insts[1:] {
+		assert.Equal(t, "SPY", inst.Name)
+	}
+
+	// Later calls are not coalesced with finished ones.
+	_, err = c.GetInstrument(ctx, spy.URL)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&n))
+}
+
+func TestRequestCoalescingCallerCancel(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	spy := s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	var n int32
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithRequestCoalescing(), robinhood.WithMiddleware(slowCalls(100*time.Millisecond, &n)))
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.GetInstrument(ctx, spy.URL)
+		done <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = c.GetInstrument(cctx, spy.URL)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// The caller that waited still gets the shared result.
+	assert.NoError(t, <-done)
+}
+
+func TestRequestCoalescingCancelsAbandonedRequest(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	spy := s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	var hang int32
+	cancelled := make(chan struct{})
+	hangOnce := func(next robinhood.RoundTripFunc) robinhood.RoundTripFunc {
+		return func(call *robinhood.Call) (*http.Response, error) {
+			if atomic.CompareAndSwapInt32(&hang, 1, 0) {
+				<-call.Request.Context().Done()
+				close(cancelled)
+				return nil, call.Request.Context().Err()
+			}
+			return next(call)
+		}
+	}
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithRequestCoalescing(), robinhood.WithMiddleware(hangOnce))
+	require.NoError(t, err)
+	atomic.StoreInt32(&hang, 1)
+
+	cctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = c.GetInstrument(cctx, spy.URL)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("abandoned request was not cancelled")
+	}
+
+	// A later caller starts a new request instead of joining the hung one.
+	inst, err := c.GetInstrument(ctx, spy.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "SPY", inst.Symbol)
+}
+
+func TestRequestCoalescingMiddlewareDest(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	spy := s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	var mu sync.Mutex
+	var dests []interface{}
+	record := func(next robinhood.RoundTripFunc) robinhood.RoundTripFunc {
+		return func(call *robinhood.Call) (*http.Response, error) {
+			mu.Lock()
+			dests = append(dests, call.Dest)
+			mu.Unlock()
+			return next(call)
+		}
+	}
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithRequestCoalescing(), robinhood.WithMiddleware(record))
+	require.NoError(t, err)
+	dests = nil
+
+	_, err = c.GetInstrument(ctx, spy.URL)
+	require.NoError(t, err)
+	require.Len(t, dests, 1)
+	assert.IsType(t, &robinhood.Instrument{}, dests[0])
+}
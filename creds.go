@@ -1,83 +1,186 @@
 package robinhood
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"os/user"
-	"path"
-	"strings"
+	"sync"
 
 	"golang.org/x/oauth2"
 )
 
-var defaultPath = ""
-
-func init() {
-	u, err := user.Current()
-	if err == nil {
-		defaultPath = path.Join(u.HomeDir, ".config", "robinhood.token")
-	}
-}
-
-// A CredsCacher takes user credentials and a file path. The token obtained
-// from the RobinHood API will be cached at the file path, and a new token will
-// not be obtained.
+// A CredsCacher takes user credentials and a place to store the token obtained
+// with them. The token obtained from the RobinHood API will be cached in the
+// Store, or at the file path if there is none, and a new token will not be
+// obtained while it is valid. Once it expires, it is renewed with its refresh
+// token if Creds is a Refresher, and only if the refresh token is rejected are
+// Creds asked for a new one.
 type CredsCacher struct {
 	Creds oauth2.TokenSource
-	Path  string
+	// Store holds the cached token. If nil, a FileStore with Path and
+	// Encryption is used.
+	Store TokenStore
+	// Path is the file the token is cached in if Store is nil. If empty,
+	// DefaultTokenPath is used.
+	Path string
+	// Encryption, if set, encrypts the cached token. A plaintext cache left
+	// by an earlier version is encrypted the next time it is read.
+	Encryption *TokenEncryption
+
+	mu sync.Mutex
 }
 
 // Token implements TokenSource. It may fail if an error is encountered
 // checking the file path provided, or if the underlying creds return an error
 // when retrieving their token.
+//
+// If the store is a LockingStore, such as a FileStore, it is locked while the
+// token is renewed, so that of several processes sharing it only one logs in.
 func (c *CredsCacher) Token() (*oauth2.Token, error) {
-	if c.Path == "" {
-		c.Path = defaultPath
-	}
+	return c.TokenContext(context.Background())
+}
 
-	mustLogin := false
+// TokenContext is like Token, but a new token is obtained from Creds with ctx
+// if they support it.
+func (c *CredsCacher) TokenContext(ctx context.Context) (*oauth2.Token, error) {
+	st := c.store()
 
-	err := os.MkdirAll(path.Dir(c.Path), 0750)
+	o, err := st.Load()
 	if err != nil {
-		return nil, fmt.Errorf("error creating path for token: %s", err)
+		return nil, err
+	}
+	if o != nil && o.Valid() {
+		return o, nil
 	}
 
-	_, err = os.Stat(c.Path)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	unlock, err := lock(st)
 	if err != nil {
-		if strings.Contains(err.Error(), "no such file") {
-			mustLogin = true
-		} else {
-			return nil, err
-		}
+		return nil, err
 	}
+	defer unlock()
 
-	if !mustLogin {
-		bs, err := ioutil.ReadFile(c.Path)
-		if err != nil {
+	// Another process may have renewed the token while we waited.
+	o, err = st.Load()
+	if err != nil {
+		return nil, err
+	}
+	if o != nil {
+		if o.Valid() {
+			return o, nil
+		}
+		tok, err := c.refresh(ctx, o.RefreshToken)
+		if err != nil && !refreshRejected(err) {
 			return nil, err
 		}
-
-		if len(bs) > 0 {
-			var o oauth2.Token
-			if err := json.Unmarshal(bs, &o); err == nil && o.Valid() {
-				return &o, err
-			}
+		if tok != nil {
+			return tok, st.Save(tok)
 		}
 	}
 
-	tok, err := c.Creds.Token()
+	tok, err := tokenContext(ctx, c.Creds)
 	if err != nil {
 		return nil, err
 	}
+	return tok, st.Save(tok)
+}
+
+// lock locks st if it is a LockingStore.
+func lock(st TokenStore) (func() error, error) {
+	ls, ok := st.(LockingStore)
+	if !ok {
+		return func() error { return nil }, nil
+	}
+	return ls.Lock()
+}
+
+func (c *CredsCacher) store() TokenStore {
+	if c.Store != nil {
+		return c.Store
+	}
+	return &FileStore{Path: c.Path, Encryption: c.Encryption}
+}
+
+// refresh renews the token with refreshToken. It returns nil and no error if
+// that is not possible.
+func (c *CredsCacher) refresh(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	if refreshToken == "" {
+		return nil, nil
+	}
+	if o, ok := c.Creds.(*OAuth); ok {
+		return o.refresh(ctx, refreshToken)
+	}
+	r, ok := c.Creds.(Refresher)
+	if !ok {
+		return nil, nil
+	}
+	return r.Refresh(refreshToken)
+}
+
+// Invalidate implements Invalidator by discarding the cached access token, so
+// that the next call to Token obtains a new one. The refresh token, if any, is
+// kept so that the new token can be obtained without logging in again.
+func (c *CredsCacher) Invalidate() error {
+	st := c.store()
 
-	f, err := os.OpenFile(c.Path, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0640)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	unlock, err := lock(st)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	defer unlock()
+
+	o, err := st.Load()
+	if err != nil || o == nil || o.RefreshToken == "" {
+		return st.Delete()
+	}
+	return st.Save(&oauth2.Token{RefreshToken: o.RefreshToken})
+}
+
+// Revoke implements Revoker by revoking the cached token, and tok if it is a
+// different one, with Creds, and then deleting the cached token. If revoking
+// fails, the cached token is kept so that it can be tried again. If Creds is
+// not a Revoker, the cached token is deleted and an error is returned if
+// there was a token to revoke.
+func (c *CredsCacher) Revoke(ctx context.Context, tok *oauth2.Token) error {
+	st := c.store()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	unlock, err := lock(st)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cached, err := st.Load()
+	if err != nil {
+		return err
 	}
-	defer f.Close()
 
-	err = json.NewEncoder(f).Encode(tok)
-	return tok, err
+	var toks []*oauth2.Token
+	if cached != nil {
+		toks = append(toks, cached)
+	}
+	if tok != nil && (cached == nil || tok.AccessToken != cached.AccessToken ||
+		tok.RefreshToken != cached.RefreshToken) {
+		toks = append(toks, tok)
+	}
+	if len(toks) == 0 {
+		return st.Delete()
+	}
+	r, ok := c.Creds.(Revoker)
+	if !ok {
+		if err := st.Delete(); err != nil {
+			return err
+		}
+		return fmt.Errorf("cannot revoke tokens of a %T", c.Creds)
+	}
+	for _, t := range toks {
+		if err := r.Revoke(ctx, t); err != nil {
+			return err
+		}
+	}
+	return st.Delete()
 }
@@ -0,0 +1,171 @@
+package robinhood_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"astuart.co/go-robinhood/v2"
+	"astuart.co/go-robinhood/v2/robinhoodtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestCredsCacherEncryption(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+
+	ep := s.Endpoints()
+	path := filepath.Join(t.TempDir(), "robinhood.token")
+	creds := &robinhood.OAuth{Username: "user", Password: "password", Endpoints: &ep}
+	cc := &robinhood.CredsCacher{Creds: creds, Path: path, Encryption: &robinhood.TokenEncryption{Passphrase: "correct horse"}}
+
+	tok, err := cc.Token()
+	require.NoError(t, err)
+
+	bs, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(bs, []byte(tok.AccessToken)))
+	assert.False(t, bytes.Contains(bs, []byte(tok.RefreshToken)))
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	cc = &robinhood.CredsCacher{Creds: creds, Path: path, Encryption: &robinhood.TokenEncryption{Passphrase: "correct horse"}}
+	tok2, err := cc.Token()
+	require.NoError(t, err)
+	assert.Equal(t, tok.AccessToken, tok2.AccessToken)
+	assert.Len(t, s.Grants(), 1)
+
+	cc = &robinhood.CredsCacher{Creds: creds, Path: path, Encryption: &robinhood.TokenEncryption{Passphrase: "wrong"}}
+	_, err = cc.Token()
+	assert.True(t, errors.Is(err, robinhood.ErrTokenDecrypt), "%v", err)
+
+	cc = &robinhood.CredsCacher{Creds: creds, Path: path}
+	_, err = cc.Token()
+	assert.True(t, errors.Is(err, robinhood.ErrTokenDecrypt), "%v", err)
+	assert.Len(t, s.Grants(), 1)
+}
+
+func TestCredsCacherMigratesPlaintext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "robinhood.token")
+	old := &oauth2.Token{AccessToken: "plain-access", RefreshToken: "plain-refresh", Expiry: time.Now().Add(time.Hour)}
+	bs, err := json.Marshal(old)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, bs, 0640))
+	require.NoError(t, os.Chmod(path, 0640))
+
+	key := bytes.Repeat([]byte{7}, 32)
+	cc := &robinhood.CredsCacher{Path: path, Encryption: &robinhood.TokenEncryption{Key: key}}
+	tok, err := cc.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "plain-access", tok.AccessToken)
+
+	bs, err = ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(bs, []byte("plain-access")))
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	cc = &robinhood.CredsCacher{Path: path, Encryption: &robinhood.TokenEncryption{Key: key}}
+	tok, err = cc.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "plain-access", tok.AccessToken)
+
+	cc = &robinhood.CredsCacher{Path: path, Encryption: &robinhood.TokenEncryption{Key: []byte("short")}}
+	_, err = cc.Token()
+	assert.Error(t, err)
+}
+
+func TestCredsCacherPlaintextPermissions(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+
+	ep := s.Endpoints()
+	path := filepath.Join(t.TempDir(), "robinhood.token")
+	cc := &robinhood.CredsCacher{Creds: &robinhood.OAuth{Username: "user", Password: "password", Endpoints: &ep}, Path: path}
+	_, err := cc.Token()
+	require.NoError(t, err)
+
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+}
+
+func TestCredsCacherSharedFile(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+
+	ep := s.Endpoints()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "robinhood.token")
+	expired, err := json.Marshal(&oauth2.Token{AccessToken: "expired", Expiry: time.Now().Add(-time.Minute)})
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, expired, 0600))
+
+	// Each cacher stands in for a separate process sharing the file.
+	const n = 8
+	toks := make([]string, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cc := &robinhood.CredsCacher{Creds: &robinhood.OAuth{Username: "user", Password: "password", Endpoints: &ep}, Path: path}
+			tok, err := cc.Token()
+			if assert.NoError(t, err) {
+				toks[i] = tok.AccessToken
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, s.Grants(), 1)
+	for _, tok := range toks {
+		assert.Equal(t, toks[0], tok)
+	}
+	assert.Equal(t, toks[0], readToken(t, path).AccessToken)
+
+	fis, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, fi := range fis {
+		names = append(names, fi.Name())
+	}
+	assert.Equal(t, []string{"robinhood.token", "robinhood.token.lock"}, names)
+}
+
+func TestTokenEncryptionNeedsKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "robinhood.token")
+	st := &robinhood.FileStore{Path: path, Encryption: &robinhood.TokenEncryption{}}
+	assert.Error(t, st.Save(&oauth2.Token{AccessToken: "access"}))
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "%v", err)
+}
+
+func TestTokenEncryptionRejectsTamperedKDF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "robinhood.token")
+	st := &robinhood.FileStore{Path: path, Encryption: &robinhood.TokenEncryption{Passphrase: "correct horse"}}
+	require.NoError(t, st.Save(&oauth2.Token{AccessToken: "access"}))
+
+	bs, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	var sealed map[string]interface{}
+	require.NoError(t, json.Unmarshal(bs, &sealed))
+	sealed["n"] = 1 << 30
+	bs, err = json.Marshal(sealed)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, bs, 0600))
+
+	st = &robinhood.FileStore{Path: path, Encryption: &robinhood.TokenEncryption{Passphrase: "correct horse"}}
+	_, err = st.Load()
+	assert.True(t, errors.Is(err, robinhood.ErrTokenDecrypt), "%v", err)
+}
@@ -16,14 +16,14 @@ type HistoricalData struct {
 
 // Historical data represents class ohlc data i.e open, high, low, close
 type Historical struct {
-	BeginsAt     string  `json:"begins_at"`
-	OpenPrice    float64 `json:"open_price,string"`
-	ClosePrice   float64 `json:"close_price,string"`
-	HighPrice    float64 `json:"high_price,string"`
-	LowPrice     float64 `json:"low_price,string"`
-	Volume       int     `json:"volume"`
-	Session      string  `json:"session"`
-	Interpolated bool    `json:"interpolated"`
+	BeginsAt     Timestamp `json:"begins_at"`
+	OpenPrice    Money     `json:"open_price"`
+	ClosePrice   Money     `json:"close_price"`
+	HighPrice    Money     `json:"high_price"`
+	LowPrice     Money     `json:"low_price"`
+	Volume       int       `json:"volume"`
+	Session      string    `json:"session"`
+	Interpolated bool      `json:"interpolated"`
 }
 
 // GetCryptoHistoricals will give the high low open, close data fro the given symbol and span
@@ -42,7 +42,7 @@ func (c *Client) GetCryptoHistoricals(ctx context.Context, cryptoID string, inte
 	}
 
 	// Build the URL
-	url := EPMarket + fmt.Sprintf("forex/historicals/%s", cryptoID) + "/?bounds=" + bounds + "&interval=" + interval + "&span=" + span
+	url := c.ep().Market + fmt.Sprintf("forex/historicals/%s", cryptoID) + "/?bounds=" + bounds + "&interval=" + interval + "&span=" + span
 
 	// Get the data
 	err := c.GetAndDecode(ctx, url, &r)
@@ -51,7 +51,7 @@ func (c *Client) GetCryptoHistoricals(ctx context.Context, cryptoID string, inte
 
 // GetDailyHistoricals will give daily high, low, ope, close data for the given symbol
 func (c *Client) GetDailyHistoricals(ctx context.Context, cryptoID string) (HistoricalData, error) {
-	url := EPMarket + fmt.Sprintf("forex/historicals/%s", cryptoID) + "/?bounds=24_7&interval=day&span=week"
+	url := c.ep().Market + fmt.Sprintf("forex/historicals/%s", cryptoID) + "/?bounds=24_7&interval=day&span=week"
 	var r = HistoricalData{}
 	err := c.GetAndDecode(ctx, url, &r)
 	return r, err
@@ -2,9 +2,9 @@ package robinhood
 
 import (
 	"bytes"
-	"strings"
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
@@ -12,8 +12,6 @@ import (
 	"encoding/json"
 
 	"net/http"
-
-	"github.com/shopspring/decimal"
 )
 
 const ethPrecision = int32(6)
@@ -21,40 +19,40 @@ const defaultPrecision = int32(8)
 
 // CryptoOrder is the payload to create a crypto currency order
 type CryptoOrder struct {
-	AccountID      string  `json:"account_id,omitempty"`
-	CurrencyPairID string  `json:"currency_pair_id,omitempty"`
-	Price          float64 `json:"price,omitempty"`
-	RefID          string  `json:"ref_id,omitempty"`
-	Side           string  `json:"side,omitempty"`
-	TimeInForce    string  `json:"time_in_force,omitempty"`
-	Quantity       float64 `json:"quantity,omitempty"`
-	Type           string  `json:"type,omitempty"`
+	AccountID      string   `json:"account_id,omitempty"`
+	CurrencyPairID string   `json:"currency_pair_id,omitempty"`
+	Price          *Money   `json:"price,omitempty"`
+	RefID          string   `json:"ref_id,omitempty"`
+	Side           string   `json:"side,omitempty"`
+	TimeInForce    string   `json:"time_in_force,omitempty"`
+	Quantity       Quantity `json:"quantity"`
+	Type           string   `json:"type,omitempty"`
 }
 
 type Execution struct {
-	EffectivePrice float64 `json:"effective_Price,string"`
-	ID             string  `json:"id"`
-	Quantity       float64 `json:"quantity,string"`
-	Timestamp      string  `json:"timestamp"`
+	EffectivePrice Money     `json:"effective_Price"`
+	ID             string    `json:"id"`
+	Quantity       Quantity  `json:"quantity"`
+	Timestamp      Timestamp `json:"timestamp"`
 }
 
 // CryptoOrderOutput holds the response from api
 type CryptoOrderOutput struct {
 	Meta
 	Account            string      `json:"account_id"`
-	AveragePrice       float64     `json:"average_price,string"`
+	AveragePrice       Money       `json:"average_price"`
 	CancelURL          string      `json:"cancel_url"`
-	CumulativeQuantity string      `json:"cumulative_quantity"`
+	CumulativeQuantity Quantity    `json:"cumulative_quantity"`
 	CurrencyPairID     string      `json:"currency_pair_id"`
 	Executions         []Execution `json:"executions"`
 	ID                 string      `json:"id"`
-	LastTransactionAt  string      `json:"last_transaction_at"`
-	Price              float64     `json:"price,string"`
-	Quantity           string      `json:"quantity"`
+	LastTransactionAt  Timestamp   `json:"last_transaction_at"`
+	Price              Money       `json:"price"`
+	Quantity           Quantity    `json:"quantity"`
 	RejectReason       string      `json:"reject_reason"`
 	Side               string      `json:"side"`
 	State              string      `json:"state"`
-	StopPrice          float64     `json:"stop_price,string"`
+	StopPrice          Money       `json:"stop_price"`
 	TimeInForce        string      `json:"time_in_force"`
 	Type               string      `json:"type"`
 
@@ -65,9 +63,9 @@ type CryptoOrderOutput struct {
 type CryptoOrderOpts struct {
 	Side            OrderSide
 	Type            OrderType
-	AmountInDollars float64
-	Quantity        float64
-	Price           float64
+	AmountInDollars Money
+	Quantity        Quantity
+	Price           Money
 	TimeInForce     TimeInForce
 	ExtendedHours   bool
 	Stop, Force     bool
@@ -75,32 +73,44 @@ type CryptoOrderOpts struct {
 
 // CryptoOrder will actually place the order
 func (c *Client) CryptoOrder(ctx context.Context, cryptoPair CryptoCurrencyPair, o CryptoOrderOpts) (*CryptoOrderOutput, error) {
-	var amountInDollars = decimal.NewFromFloat32(float32(o.AmountInDollars))
-	var price = decimal.NewFromFloat32(float32(o.Price))
-	var precision = defaultPrecision
-	if cryptoPair.CyrptoAssetCurrency.Code == "ETH" {
-		precision = ethPrecision
+	ca, err := c.ActiveCryptoAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without an explicit quantity, buy as much as AmountInDollars pays for.
+	quantity := o.Quantity
+	if quantity.IsZero() && !o.Price.IsZero() {
+		var precision = defaultPrecision
+		if cryptoPair.CyrptoAssetCurrency.Code == "ETH" {
+			precision = ethPrecision
+		}
+		quantity = NewQuantity(o.AmountInDollars.DivRound(o.Price.Decimal, precision))
+	}
+	if !quantity.IsPositive() {
+		return nil, fmt.Errorf("crypto order needs a positive Quantity, or an AmountInDollars and a Price")
 	}
-	var quantity = amountInDollars.DivRound(price, precision)
-	exactQuantity, _ := quantity.Float64()
 	a := CryptoOrder{
-		AccountID:      c.CryptoAccount.ID,
+		AccountID:      ca.ID,
 		CurrencyPairID: cryptoPair.ID,
-		Quantity:       exactQuantity,
-		Price:          o.Price,
+		Quantity:       quantity,
 		RefID:          uuid.New().String(),
 		Side:           strings.ToLower(o.Side.String()),
 		TimeInForce:    strings.ToLower(o.TimeInForce.String()),
 		Type:           strings.ToLower(o.Type.String()),
 	}
 
+	if !o.Price.IsZero() {
+		a.Price = &o.Price
+	}
+
 	payload, err := json.Marshal(a)
 
 	if err != nil {
 		return nil, err
 	}
 
-	post, err := http.NewRequest("POST", EPCryptoOrders, bytes.NewReader(payload))
+	post, err := http.NewRequest("POST", c.ep().CryptoOrders, bytes.NewReader(payload))
 	if err != nil {
 		return nil, fmt.Errorf("could not create Crypto http.Request: %v", err)
 	}
@@ -136,7 +146,7 @@ func (o CryptoOrderOutput) Cancel(ctx context.Context) error {
 
 // GetCryptoOrder will get the order info from robinhood
 func (c *Client) GetCryptoOrder(ctx context.Context, orderID string) (*CryptoOrderOutput, error) {
-	url := EPCryptoOrders + orderID
+	url := c.ep().CryptoOrders + orderID
 	get, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
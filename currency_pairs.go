@@ -11,9 +11,9 @@ import (
 type CryptoCurrencyPair struct {
 	CyrptoAssetCurrency    AssetCurrency `json:"asset_currency"`
 	ID                     string        `json:"id"`
-	MaxOrderSize           float64       `json:"max_order_size,string"`
-	MinOrderPriceIncrement float64       `json:"min_order_price_increment,string"`
-	MinOrderSize           float64       `json:"min_order_size,string"`
+	MaxOrderSize           Quantity      `json:"max_order_size"`
+	MinOrderPriceIncrement Money         `json:"min_order_price_increment"`
+	MinOrderSize           Quantity      `json:"min_order_size"`
 	Name                   string        `json:"name"`
 	CrytoQuoteCurrency     QuoteCurrency `json:"quote_currency"`
 	Symbol                 string        `json:"symbol"`
@@ -22,27 +22,28 @@ type CryptoCurrencyPair struct {
 
 // QuoteCurrency holds info about currency you can use to buy the cyrpto currency
 type QuoteCurrency struct {
-	Code      string  `json:"code"`
-	ID        string  `json:"id"`
-	Increment float64 `json:"increment,string"`
-	Name      string  `json:"name"`
-	Type      string  `json:"type"`
+	Code      string `json:"code"`
+	ID        string `json:"id"`
+	Increment Money  `json:"increment"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
 }
 
 // AssetCurrency has code and id of cryptocurrency
 type AssetCurrency struct {
-	BrandColor string  `json:"brand_color"`
-	Code       string  `json:"code"`
-	ID         string  `json:"id"`
-	Increment  float64 `json:"increment,string"`
-	Name       string  `json:"name"`
+	BrandColor string   `json:"brand_color"`
+	Code       string   `json:"code"`
+	ID         string   `json:"id"`
+	Increment  Quantity `json:"increment"`
+	Name       string   `json:"name"`
 }
 
-// GetCryptoCurrencyPairs will give which crypto currencies are tradeable and corresponding ids
+// GetCryptoCurrencyPairs will give which crypto currencies are tradeable and corresponding ids.
+// If the client has a Cache, it is consulted first.
 func (c *Client) GetCryptoCurrencyPairs(ctx context.Context) ([]CryptoCurrencyPair, error) {
-	var r struct{ Results []CryptoCurrencyPair }
-	err := c.GetAndDecode(ctx, EPCryptoCurrencyPairs, &r)
-	return r.Results, err
+	return cached(c, CacheCurrencyPairs, "", func() ([]CryptoCurrencyPair, error) {
+		return c.IterCryptoCurrencyPairs().All(ctx)
+	})
 }
 
 // GetCryptoInstrument will take standard crypto symbol and return usable information
@@ -0,0 +1,183 @@
+package robinhood
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// scrypt parameters for deriving keys from passphrases.
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltLen      = 16
+)
+
+// ErrTokenDecrypt is returned when a cached token cannot be decrypted, for
+// example because the passphrase or key is wrong.
+var ErrTokenDecrypt = errors.New("could not decrypt cached token")
+
+// errNoTokenKey is returned by a TokenEncryption with neither a passphrase
+// nor a key.
+var errNoTokenKey = errors.New("token encryption needs a Passphrase or Key")
+
+// TokenEncryption encrypts cached tokens at rest with AES-256-GCM.
+type TokenEncryption struct {
+	// Passphrase is used to derive the key with scrypt, using a random salt
+	// stored alongside the token.
+	Passphrase string
+	// Key, if set, is a 32-byte key used as is instead of a passphrase.
+	Key []byte
+
+	mu      sync.Mutex
+	salt    []byte
+	derived []byte
+}
+
+// sealedToken is the file format of an encrypted token.
+type sealedToken struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf,omitempty"`
+	N          int    `json:"n,omitempty"`
+	R          int    `json:"r,omitempty"`
+	P          int    `json:"p,omitempty"`
+	Salt       []byte `json:"salt,omitempty"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// isSealed reports whether bs holds an encrypted token rather than a
+// plaintext one.
+func isSealed(bs []byte) bool {
+	var s sealedToken
+	return json.Unmarshal(bs, &s) == nil && len(s.Ciphertext) > 0
+}
+
+// seal encrypts plaintext into the sealedToken file format.
+func (e *TokenEncryption) seal(plaintext []byte) ([]byte, error) {
+	if e.Key == nil && e.Passphrase == "" {
+		return nil, errNoTokenKey
+	}
+
+	s := sealedToken{Version: 1}
+	var key []byte
+	if e.Key != nil {
+		key = e.Key
+	} else {
+		var err error
+		s.KDF, s.N, s.R, s.P = "scrypt", scryptN, scryptR, scryptP
+		s.Salt, key, err = e.sealingKey()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	s.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, s.Nonce); err != nil {
+		return nil, err
+	}
+	s.Ciphertext = gcm.Seal(nil, s.Nonce, plaintext, nil)
+	return json.Marshal(s)
+}
+
+// open decrypts a token in the sealedToken file format.
+func (e *TokenEncryption) open(bs []byte) ([]byte, error) {
+	var s sealedToken
+	if err := json.Unmarshal(bs, &s); err != nil {
+		return nil, err
+	}
+
+	key := e.Key
+	if key == nil {
+		if e.Passphrase == "" {
+			return nil, errNoTokenKey
+		}
+		if s.KDF != "scrypt" {
+			return nil, fmt.Errorf("%w: unsupported key derivation %q", ErrTokenDecrypt, s.KDF)
+		}
+		var err error
+		key, err = e.openingKey(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("%w: bad nonce", ErrTokenDecrypt)
+	}
+	pt, err := gcm.Open(nil, s.Nonce, s.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrTokenDecrypt
+	}
+	return pt, nil
+}
+
+// sealingKey returns the salt and key to encrypt with, deriving them on first
+// use. Reusing them avoids running scrypt on every save.
+func (e *TokenEncryption) sealingKey() ([]byte, []byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.derived != nil {
+		return e.salt, e.derived, nil
+	}
+	salt := make([]byte, saltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, nil, err
+	}
+	key, err := scrypt.Key([]byte(e.Passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, nil, err
+	}
+	e.salt, e.derived = salt, key
+	return salt, key, nil
+}
+
+// openingKey derives the key for s, remembering it for later saves.
+func (e *TokenEncryption) openingKey(s sealedToken) ([]byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// Only the parameters seal uses are accepted, so that a tampered file
+	// cannot make scrypt use arbitrary amounts of memory.
+	if s.N != scryptN || s.R != scryptR || s.P != scryptP {
+		return nil, fmt.Errorf("%w: unsupported scrypt parameters N=%d r=%d p=%d", ErrTokenDecrypt, s.N, s.R, s.P)
+	}
+	if e.derived != nil && string(e.salt) == string(s.Salt) {
+		return e.derived, nil
+	}
+	key, err := scrypt.Key([]byte(e.Passphrase), s.Salt, s.N, s.R, s.P, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenDecrypt, err)
+	}
+	e.salt, e.derived = s.Salt, key
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("token encryption key must be 32 bytes, not %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,102 @@
+package robinhood
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Endpoints is the full set of URLs a Client uses to talk to the Robinhood
+// API. The zero value is not useful; use NewEndpoints or DefaultEndpoints.
+// Pointing a Client at a different Endpoints allows the use of local fake
+// servers, recording proxies or staging hosts.
+type Endpoints struct {
+	Base, CryptoBase, PhoenixBase string
+
+	Login        string
+	Revoke       string
+	Challenge    string
+	Accounts     string
+	Quotes       string
+	Portfolios   string
+	Positions    string
+	Watchlists   string
+	Instruments  string
+	Fundamentals string
+	Orders       string
+	Options      string
+	Market       string
+	OptionQuote  string
+
+	CryptoOrders        string
+	CryptoAccount       string
+	CryptoCurrencyPairs string
+	CryptoHoldings      string
+	CryptoPortfolio     string
+
+	AccountUnified string
+}
+
+// DefaultEndpoints are the production Robinhood API endpoints.
+var DefaultEndpoints = NewEndpoints(EPBase, EPCryptoBase, PhoenixEPBase)
+
+// NewEndpoints returns the full endpoint set rooted at the given API, crypto
+// (nummus) and phoenix base URLs. A trailing slash is added to each base if it
+// is missing.
+func NewEndpoints(base, cryptoBase, phoenixBase string) Endpoints {
+	base = withSlash(base)
+	cryptoBase = withSlash(cryptoBase)
+	phoenixBase = withSlash(phoenixBase)
+
+	return Endpoints{
+		Base:        base,
+		CryptoBase:  cryptoBase,
+		PhoenixBase: phoenixBase,
+
+		Login:        base + "oauth2/token/",
+		Revoke:       base + "oauth2/revoke_token/",
+		Challenge:    base + "challenge/",
+		Accounts:     base + "accounts/",
+		Quotes:       base + "quotes/",
+		Portfolios:   base + "portfolios/",
+		Positions:    base + "positions/",
+		Watchlists:   base + "watchlists/",
+		Instruments:  base + "instruments/",
+		Fundamentals: base + "fundamentals/",
+		Orders:       base + "orders/",
+		Options:      base + "options/",
+		Market:       base + "marketdata/",
+		OptionQuote:  base + "marketdata/options/",
+
+		CryptoOrders:        cryptoBase + "orders/",
+		CryptoAccount:       cryptoBase + "accounts/",
+		CryptoCurrencyPairs: cryptoBase + "currency_pairs/",
+		CryptoHoldings:      cryptoBase + "holdings/",
+		CryptoPortfolio:     cryptoBase + "portfolios/",
+
+		AccountUnified: phoenixBase + PhoenixEPAccountUnified,
+	}
+}
+
+// Name returns the name of the endpoint that u belongs to, which is the name of
+// the Endpoints field holding the longest prefix of u (e.g. "Orders" or
+// "OptionQuote"). It returns "" if u is not under any endpoint.
+func (e Endpoints) Name(u string) string {
+	v := reflect.ValueOf(e)
+	t := v.Type()
+
+	name, longest := "", 0
+	for i := 0; i < t.NumField(); i++ {
+		p := v.Field(i).String()
+		if p != "" && len(p) > longest && strings.HasPrefix(u, p) {
+			name, longest = t.Field(i).Name, len(p)
+		}
+	}
+	return name
+}
+
+func withSlash(s string) string {
+	if strings.HasSuffix(s, "/") {
+		return s
+	}
+	return s + "/"
+}
@@ -0,0 +1,123 @@
+package robinhood
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Well-known API failures. An *APIError matches these with errors.Is, so
+// callers can branch on the kind of failure without inspecting messages.
+var (
+	ErrUnauthorized            = errors.New("unauthorized")
+	ErrThrottled               = errors.New("request was throttled")
+	ErrNotFound                = errors.New("not found")
+	ErrInsufficientBuyingPower = errors.New("insufficient buying power")
+	ErrMarketClosed            = errors.New("market is closed")
+)
+
+// An APIError is returned when the Robinhood API responds with an error
+// status. It keeps the status code, request URL and raw body along with any
+// messages that could be decoded from it.
+type APIError struct {
+	StatusCode int
+	Status     string
+	URL        string
+	Body       []byte
+
+	// Detail and NonFieldErrors hold the API's top-level error messages, if
+	// any. Fields holds the whole decoded body, including per-field errors.
+	Detail         string
+	NonFieldErrors []string
+	Fields         ErrorMap
+
+	// RetryAfter is how long the API asked the client to wait before
+	// retrying a throttled or unavailable request.
+	RetryAfter time.Duration
+
+	retryable bool
+	hinted    bool // whether the API gave RetryAfter
+}
+
+// newAPIError builds an APIError from an error response and its body.
+func newAPIError(res *http.Response, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Body:       body,
+	}
+	if res.Request != nil && res.Request.URL != nil {
+		e.URL = res.Request.URL.String()
+	}
+
+	var m ErrorMap
+	if json.Unmarshal(body, &m) != nil {
+		return e
+	}
+	e.Fields = m
+
+	if d, ok := m["detail"].(string); ok {
+		e.Detail = d
+	}
+	if nfe, ok := m["non_field_errors"].([]interface{}); ok {
+		for _, v := range nfe {
+			if s, ok := v.(string); ok {
+				e.NonFieldErrors = append(e.NonFieldErrors, s)
+			}
+		}
+	}
+	return e
+}
+
+// Message returns the most specific human-readable message in the response.
+func (e *APIError) Message() string {
+	switch {
+	case e.Detail != "":
+		return e.Detail
+	case len(e.NonFieldErrors) > 0:
+		return strings.Join(e.NonFieldErrors, " ")
+	case len(e.Fields) > 0:
+		return strings.TrimPrefix(e.Fields.Error(), errorMapPrefix)
+	}
+	return strings.TrimSpace(string(e.Body))
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("Error returned from API (%s %s): %s", e.Status, e.URL, e.Message())
+}
+
+// Unwrap returns the decoded error body, so errors.As may still be used to
+// retrieve an ErrorMap.
+func (e *APIError) Unwrap() error {
+	if e.Fields == nil {
+		return nil
+	}
+	return e.Fields
+}
+
+// Is reports whether the error matches one of the package's sentinel errors.
+func (e *APIError) Is(target error) bool {
+	msg := strings.ToLower(e.Message())
+
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized ||
+			strings.Contains(msg, "invalid token") ||
+			strings.Contains(msg, "credentials were not provided")
+	case ErrThrottled:
+		return e.StatusCode == http.StatusTooManyRequests ||
+			strings.Contains(msg, "request was throttled")
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrInsufficientBuyingPower:
+		return strings.Contains(msg, "buying power")
+	case ErrMarketClosed:
+		return strings.Contains(msg, "market is closed") ||
+			strings.Contains(msg, "market closed") ||
+			strings.Contains(msg, "outside of market hours")
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package robinhood_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"astuart.co/go-robinhood/v2"
+	"astuart.co/go-robinhood/v2/robinhoodtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestAPIErrorSentinels(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	inst := s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx)
+	require.NoError(t, err)
+
+	asrt := assert.New(t)
+
+	_, err = c.Order(ctx, &inst, robinhood.OrderOpts{Side: robinhood.Buy, Quantity: robinhood.QuantityFromInt(1000)})
+	asrt.True(errors.Is(err, robinhood.ErrInsufficientBuyingPower), "%v", err)
+	asrt.False(errors.Is(err, robinhood.ErrMarketClosed))
+
+	var apiErr *robinhood.APIError
+	if asrt.True(errors.As(err, &apiErr)) {
+		asrt.Equal(http.StatusBadRequest, apiErr.StatusCode)
+		asrt.Equal(robinhoodtest.DetailInsufficientBuyingPower, apiErr.Detail)
+		asrt.Equal(c.Endpoints.Orders, apiErr.URL)
+		asrt.NotEmpty(apiErr.Body)
+	}
+
+	var em robinhood.ErrorMap
+	asrt.True(errors.As(err, &em))
+
+	s.SetMarketOpen(false)
+	_, err = c.Order(ctx, &inst, robinhood.OrderOpts{Side: robinhood.Buy, Quantity: robinhood.QuantityFromInt(1)})
+	asrt.True(errors.Is(err, robinhood.ErrMarketClosed), "%v", err)
+	if asrt.True(errors.As(err, &apiErr)) {
+		asrt.Equal([]string{robinhoodtest.DetailMarketClosed}, apiErr.NonFieldErrors)
+	}
+
+	_, err = c.GetInstrument(ctx, inst.URL+"nope/")
+	asrt.True(errors.Is(err, robinhood.ErrNotFound), "%v", err)
+
+	bad, err := robinhood.Dial(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "bad"}),
+		robinhood.WithEndpoints(s.Endpoints()))
+	asrt.True(errors.Is(err, robinhood.ErrUnauthorized), "%v", err)
+	asrt.NotNil(bad)
+}
+
+func TestAPIErrorThrottled(t *testing.T) {
+	err := error(&robinhood.APIError{
+		StatusCode: http.StatusTooManyRequests,
+		Status:     "429 Too Many Requests",
+		Detail:     "Request was throttled. Expected available in 13 seconds.",
+	})
+
+	assert.True(t, errors.Is(err, robinhood.ErrThrottled))
+	assert.False(t, errors.Is(err, robinhood.ErrUnauthorized))
+	assert.Contains(t, err.Error(), "Expected available in 13 seconds")
+}
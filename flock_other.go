@@ -0,0 +1,12 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package robinhood
+
+import "os"
+
+// flock is a no-op where advisory locks are not supported; writes are still
+// atomic, but processes may renew the token concurrently.
+func flock(f *os.File) error { return nil }
+
+// funlock is a no-op where advisory locks are not supported.
+func funlock(f *os.File) error { return nil }
@@ -6,24 +6,29 @@ import (
 )
 
 type Fundamental struct {
-	Open          float64 `json:"open,string"`
-	High          float64 `json:"high,string"`
-	Low           float64 `json:"low,string"`
-	Volume        float64 `json:"volume,string"`
-	AverageVolume float64 `json:"average_volume,string"`
-	High52Weeks   float64 `json:"high_52_weeks,string"`
-	DividendYield float64 `json:"dividend_yield,string"`
-	Low52Weeks    float64 `json:"low_52_weeks,string"`
-	MarketCap     float64 `json:"market_cap,string"`
-	PERatio       float64 `json:"pe_ratio,string"`
-	Description   string  `json:"description"`
-	Instrument    string  `json:"instrument"`
+	Open          Money    `json:"open"`
+	High          Money    `json:"high"`
+	Low           Money    `json:"low"`
+	Volume        Quantity `json:"volume"`
+	AverageVolume Quantity `json:"average_volume"`
+	High52Weeks   Money    `json:"high_52_weeks"`
+	DividendYield float64  `json:"dividend_yield,string"`
+	Low52Weeks    Money    `json:"low_52_weeks"`
+	MarketCap     Money    `json:"market_cap"`
+	PERatio       float64  `json:"pe_ratio,string"`
+	Description   string   `json:"description"`
+	Instrument    string   `json:"instrument"`
 }
 
-// GetFundamentals returns fundamental data for the list of stocks provided.
+// GetFundamentals returns fundamental data for the list of stocks provided,
+// in the order given. Like GetQuote, large lists are requested in batches and
+// per-symbol failures are reported in a *BatchError.
 func (c *Client) GetFundamentals(ctx context.Context, stocks ...string) ([]Fundamental, error) {
-	url := EPFundamentals + "?symbols=" + strings.Join(stocks, ",")
-	var r struct{ Results []Fundamental }
-	err := c.GetAndDecode(ctx, url, &r)
-	return r.Results, err
+	rs, err := batch(ctx, c, stocks, func(ctx context.Context, chunk []string) ([]*Fundamental, error) {
+		url := c.ep().Fundamentals + "?symbols=" + strings.Join(chunk, ",")
+		var r struct{ Results []*Fundamental }
+		err := c.GetAndDecode(ctx, url, &r)
+		return r.Results, err
+	})
+	return derefAll(rs), err
 }
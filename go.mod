@@ -3,15 +3,19 @@ module astuart.co/go-robinhood/v2
 require (
 	github.com/davecgh/go-spew v1.1.1
 	github.com/google/uuid v1.1.0
-	github.com/hashicorp/go-multierror v1.0.0
-	github.com/pkg/errors v0.8.0
-	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/pkg/errors v0.9.1
 	github.com/shopspring/decimal v0.0.0-20200105231215-408a2507e114
 	github.com/stretchr/testify v1.2.2
-	golang.org/x/net v0.0.0-20181207154023-610586996380 // indirect
+	golang.org/x/crypto v0.21.0
 	golang.org/x/oauth2 v0.0.0-20181203162652-d668ce993890
 	golang.org/x/sync v0.0.0-20181108010431-42b317875d0f
+)
+
+require (
+	github.com/golang/protobuf v1.2.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.21.0 // indirect
 	google.golang.org/appengine v1.3.0 // indirect
 )
 
-go 1.15
+go 1.21
@@ -15,7 +15,7 @@ type Instrument struct {
 	FractionalTradability string      `json:"fractional_tradability"`
 	Fundamentals          string      `json:"fundamentals"`
 	ID                    string      `json:"id"`
-	ListDate              string      `json:"list_date"`
+	ListDate              Date        `json:"list_date"`
 	MaintenanceRatio      string      `json:"maintenance_ratio"`
 	MarginInitialRatio    string      `json:"margin_initial_ratio"`
 	Market                string      `json:"market"`
@@ -42,27 +42,35 @@ func (i Instrument) OrderSymbol() string {
 	return i.Symbol
 }
 
-// GetInstrument returns an Instrument given a URL
+// GetInstrument returns an Instrument given a URL. If the client has a Cache,
+// it is consulted first.
 func (c *Client) GetInstrument(ctx context.Context, instURL string) (*Instrument, error) {
-	var i Instrument
-	err := c.GetAndDecode(ctx, instURL, &i)
-	if err != nil {
-		return nil, err
-	}
-	return &i, err
+	return cached(c, CacheInstruments, instURL, func() (*Instrument, error) {
+		var i Instrument
+		err := c.GetAndDecode(ctx, instURL, &i)
+		if err != nil {
+			return nil, err
+		}
+		c.cache.set(CacheInstruments, i.Symbol, &i)
+		return &i, err
+	})
 }
 
-// GetInstrumentForSymbol returns an Instrument given a ticker symbol
+// GetInstrumentForSymbol returns an Instrument given a ticker symbol. If the
+// client has a Cache, it is consulted first.
 func (c *Client) GetInstrumentForSymbol(ctx context.Context, sym string) (*Instrument, error) {
-	var i struct {
-		Results []Instrument
-	}
-	err := c.GetAndDecode(ctx, EPInstruments+"?symbol="+sym, &i)
-	if err != nil {
-		return nil, err
-	}
-	if len(i.Results) < 1 {
-		return nil, fmt.Errorf("no results")
-	}
-	return &i.Results[0], err
+	return cached(c, CacheInstruments, sym, func() (*Instrument, error) {
+		var i struct {
+			Results []Instrument
+		}
+		err := c.GetAndDecode(ctx, c.ep().Instruments+"?symbol="+sym, &i)
+		if err != nil {
+			return nil, err
+		}
+		if len(i.Results) < 1 {
+			return nil, fmt.Errorf("no results")
+		}
+		c.cache.set(CacheInstruments, i.Results[0].URL, &i.Results[0])
+		return &i.Results[0], err
+	})
 }
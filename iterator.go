@@ -0,0 +1,197 @@
+package robinhood
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// ErrPageLimit is returned by Iterator.Err when iteration stopped because
+// MaxPages pages had been fetched and more remained.
+var ErrPageLimit = errors.New("page limit reached")
+
+// An Iterator lazily walks every result of a paginated list endpoint,
+// following the "next" link of each page as it is needed. Typical use:
+//
+//	it := c.IterPositions(robinhood.PositionParams{NonZero: true})
+//	for it.Next(ctx) {
+//		p := it.Value()
+//		// ...
+//	}
+//	if err := it.Err(); err != nil {
+//		// ...
+//	}
+type Iterator[T any] struct {
+	// MaxPages, if positive, bounds the number of pages fetched. Iteration
+	// stops with ErrPageLimit if more pages remain.
+	MaxPages int
+
+	c     *Client
+	setup func(*T)
+
+	pager Pager
+	buf   []T
+	cur   T
+	pages int
+	err   error
+	done  bool
+}
+
+// NewIterator returns an Iterator over the list endpoint at url, which
+// returns pages of results of type T.
+func NewIterator[T any](c *Client, url string) *Iterator[T] {
+	return newIterator[T](c, url, nil)
+}
+
+// newIterator returns an Iterator that calls setup on each result before it
+// is returned, e.g. to attach the client.
+func newIterator[T any](c *Client, url string, setup func(*T)) *Iterator[T] {
+	return &Iterator[T]{
+		c:     c,
+		setup: setup,
+		pager: Pager{Next: url},
+	}
+}
+
+// Next advances to the next result, fetching the next page if needed. It
+// returns false when there are no more results or an error occurred; check
+// Err to tell the two apart.
+func (it *Iterator[T]) Next(ctx context.Context) bool {
+	for len(it.buf) == 0 {
+		if it.done || !it.fetch(ctx) {
+			return false
+		}
+	}
+
+	it.cur, it.buf = it.buf[0], it.buf[1:]
+	if it.setup != nil {
+		it.setup(&it.cur)
+	}
+	return true
+}
+
+// fetch retrieves the next page into the buffer.
+func (it *Iterator[T]) fetch(ctx context.Context) bool {
+	if !it.pager.HasMore() {
+		it.done = true
+		return false
+	}
+	if it.MaxPages > 0 && it.pages >= it.MaxPages {
+		it.done, it.err = true, ErrPageLimit
+		return false
+	}
+	if err := ctx.Err(); err != nil {
+		it.done, it.err = true, err
+		return false
+	}
+
+	var page struct {
+		Pager
+		Results []T
+	}
+	err := it.pager.GetNext(ctx, it.c, &page)
+	if err == io.EOF {
+		it.done = true
+		return false
+	}
+	if err != nil {
+		it.done, it.err = true, err
+		return false
+	}
+
+	it.pages++
+	it.pager = page.Pager
+	it.buf = page.Results
+	return true
+}
+
+// Value returns the current result.
+func (it *Iterator[T]) Value() T {
+	return it.cur
+}
+
+// Err returns the error that stopped iteration, if any.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Pages returns the number of pages fetched so far.
+func (it *Iterator[T]) Pages() int {
+	return it.pages
+}
+
+// All drains the iterator, returning every remaining result. If an error
+// occurs, the results retrieved before it are returned along with it.
+func (it *Iterator[T]) All(ctx context.Context) ([]T, error) {
+	var out []T
+	for it.Next(ctx) {
+		out = append(out, it.Value())
+	}
+	return out, it.Err()
+}
+
+// IterAccounts iterates over the brokerage accounts associated with the
+// client's login.
+func (c *Client) IterAccounts() *Iterator[Account] {
+	return NewIterator[Account](c, c.ep().Accounts)
+}
+
+// IterCryptoAccounts iterates over the crypto accounts associated with the
+// client's login.
+func (c *Client) IterCryptoAccounts() *Iterator[CryptoAccount] {
+	return NewIterator[CryptoAccount](c, c.ep().CryptoAccount)
+}
+
+// IterPositions iterates over the positions matching p.
+func (c *Client) IterPositions(p PositionParams) *Iterator[Position] {
+	return NewIterator[Position](c, withQuery(c.ep().Positions, p.encode()))
+}
+
+// IterOptionPositions iterates over the aggregate option positions matching
+// p.
+func (c *Client) IterOptionPositions(p PositionParams) *Iterator[OptionPostion] {
+	return NewIterator[OptionPostion](c, withQuery(c.ep().Options+"aggregate_positions/", p.encode()))
+}
+
+// IterCryptoPositions iterates over the crypto holdings of the account.
+func (c *Client) IterCryptoPositions() *Iterator[CryptoPosition] {
+	return NewIterator[CryptoPosition](c, c.ep().CryptoHoldings)
+}
+
+// IterOrders iterates over every order made by this client, newest first.
+func (c *Client) IterOrders() *Iterator[OrderOutput] {
+	return newIterator(c, c.ep().Orders, func(o *OrderOutput) {
+		o.client = c
+	})
+}
+
+// IterOptionsOrders iterates over every options order, as raw JSON.
+func (c *Client) IterOptionsOrders() *Iterator[json.RawMessage] {
+	return NewIterator[json.RawMessage](c, c.ep().Options+"orders/")
+}
+
+// IterWatchlists iterates over the watchlists of the client's login.
+func (c *Client) IterWatchlists() *Iterator[Watchlist] {
+	return newIterator(c, c.ep().Watchlists, func(w *Watchlist) {
+		w.Client = c
+	})
+}
+
+// IterPortfolios iterates over the portfolios of the client's accounts.
+func (c *Client) IterPortfolios() *Iterator[Portfolio] {
+	return NewIterator[Portfolio](c, c.ep().Portfolios)
+}
+
+// IterCryptoCurrencyPairs iterates over every crypto currency pair.
+func (c *Client) IterCryptoCurrencyPairs() *Iterator[CryptoCurrencyPair] {
+	return NewIterator[CryptoCurrencyPair](c, c.ep().CryptoCurrencyPairs)
+}
+
+// withQuery appends the encoded query q to u, if q is not empty.
+func withQuery(u, q string) string {
+	if q == "" {
+		return u
+	}
+	return u + "?" + q
+}
@@ -0,0 +1,110 @@
+package robinhood_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"astuart.co/go-robinhood/v2"
+	"astuart.co/go-robinhood/v2/robinhoodtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIterPositions(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.PageSize = 2
+	for _, sym := range []string{"A", "B", "C", "D", "E"} {
+		s.SetPosition(s.AddStock(sym, robinhood.MustMoney("10")), robinhood.QuantityFromInt(1), robinhood.MustMoney("10"))
+	}
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx)
+	require.NoError(t, err)
+
+	ps, err := c.GetPositions(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, ps, 5)
+
+	// Stopping early fetches only the pages needed.
+	it := c.IterPositions(robinhood.PositionParams{NonZero: true})
+	n := 0
+	for it.Next(ctx) {
+		n++
+		if n == 3 {
+			break
+		}
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, 2, it.Pages())
+
+	it = c.IterPositions(robinhood.PositionParams{})
+	it.MaxPages = 2
+	ps, err = it.All(ctx)
+	assert.True(t, errors.Is(err, robinhood.ErrPageLimit), "%v", err)
+	assert.Len(t, ps, 4)
+}
+
+func TestIterOrdersSetsClient(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.PageSize = 1
+	inst := s.AddStock("SPY", robinhood.MustMoney("1"))
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := c.Order(ctx, &inst, robinhood.OrderOpts{Side: robinhood.Buy, Type: robinhood.Limit, Price: robinhood.MustMoney("0.5"), Quantity: robinhood.QuantityFromInt(1)})
+		require.NoError(t, err)
+	}
+
+	it := c.IterOrders()
+	for it.Next(ctx) {
+		o := it.Value()
+		assert.NoError(t, o.Cancel(ctx))
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, 3, it.Pages())
+}
+
+func TestIteratorContext(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+
+	c, err := s.Dial(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	it := c.IterAccounts()
+	assert.False(t, it.Next(ctx))
+	assert.Equal(t, context.Canceled, it.Err())
+}
+
+func TestIterInstrumentsStableOrder(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.PageSize = 5
+	for i := 0; i < 40; i++ {
+		s.AddStock(fmt.Sprintf("S%02d", i), robinhood.MustMoney("10"))
+	}
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx)
+	require.NoError(t, err)
+
+	is, err := robinhood.NewIterator[robinhood.Instrument](c, s.Endpoints().Instruments).All(ctx)
+	require.NoError(t, err)
+	seen := map[string]bool{}
+	for _, i := range is {
+		seen[i.Symbol] = true
+	}
+	assert.Len(t, is, 40)
+	assert.Len(t, seen, 40)
+	assert.Equal(t, "S00", is[0].Symbol)
+}
@@ -0,0 +1,127 @@
+package robinhood
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+// sensitiveKeys are request headers, form fields and JSON keys whose values
+// are never logged.
+var sensitiveKeys = map[string]bool{
+	"authorization": true,
+	"cookie":        true,
+	"password":      true,
+	"mfa_code":      true,
+	"access_token":  true,
+	"refresh_token": true,
+	"device_token":  true,
+}
+
+// LoggingMiddleware logs every call with the given logger. Each call is logged
+// at Info level, or Error level if it failed, with its method, endpoint, URL,
+// destination type, status and latency. At Debug level the request headers
+// and body are included too, with credentials and tokens redacted.
+func LoggingMiddleware(l *slog.Logger) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(call *Call) (*http.Response, error) {
+			req := call.Request
+			ctx := req.Context()
+
+			attrs := []slog.Attr{
+				slog.String("method", req.Method),
+				slog.String("endpoint", call.Endpoint),
+				slog.String("url", req.URL.String()),
+				slog.String("dest", fmt.Sprintf("%T", call.Dest)),
+			}
+			if l.Enabled(ctx, slog.LevelDebug) {
+				attrs = append(attrs,
+					slog.Any("headers", redactHeaders(req.Header)),
+					slog.String("body", redactBody(req)),
+				)
+			}
+
+			start := time.Now()
+			res, err := next(call)
+			attrs = append(attrs, slog.Duration("latency", time.Since(start)))
+
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+				l.LogAttrs(ctx, slog.LevelError, "robinhood request failed", attrs...)
+				return res, err
+			}
+
+			attrs = append(attrs, slog.Int("status", res.StatusCode))
+			l.LogAttrs(ctx, slog.LevelInfo, "robinhood request", attrs...)
+			return res, nil
+		}
+	}
+}
+
+func redactHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if sensitiveKeys[strings.ToLower(k)] {
+			out[k] = redacted
+			continue
+		}
+		out[k] = strings.Join(v, ", ")
+	}
+	return out
+}
+
+// redactBody returns a copy of the request body with sensitive values
+// redacted, leaving the request itself untouched.
+func redactBody(req *http.Request) string {
+	if req.GetBody == nil {
+		return ""
+	}
+	rc, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer rc.Close()
+
+	bs, err := ioutil.ReadAll(rc)
+	if err != nil || len(bs) == 0 {
+		return ""
+	}
+
+	ct, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch ct {
+	case "application/x-www-form-urlencoded":
+		v, err := url.ParseQuery(string(bs))
+		if err != nil {
+			return redacted
+		}
+		for k := range v {
+			if sensitiveKeys[k] {
+				v.Set(k, redacted)
+			}
+		}
+		return v.Encode()
+	case "application/json":
+		var m map[string]interface{}
+		if err := json.Unmarshal(bs, &m); err != nil {
+			return redacted
+		}
+		for k := range m {
+			if sensitiveKeys[k] {
+				m[k] = redacted
+			}
+		}
+		out, _ := json.Marshal(m)
+		return string(out)
+	}
+
+	// Unknown formats may contain anything, so they are never logged.
+	return redacted
+}
@@ -6,28 +6,28 @@ import (
 )
 
 type EntryPrice struct {
-	Amount        string
+	Amount        Money
 	Currency_code string
 }
 
 type PriceBookEntry struct {
 	Side     string
 	Price    EntryPrice
-	Quantity float64
+	Quantity Quantity
 }
 
 type PriceBookData struct {
 	Asks []PriceBookEntry `json:"asks"`
 	Bids []PriceBookEntry `json:"bids"`
 
-	InstrumentID string `json:"instrument_id"`
-	UpdatedAt    string `json:"updated_at"`
+	InstrumentID string    `json:"instrument_id"`
+	UpdatedAt    Timestamp `json:"updated_at"`
 }
 
 // Pricebook get the current snapshot of the pricebook data
 func (c *Client) Pricebook(ctx context.Context, instrumentID string) (*PriceBookData, error) {
 	var out PriceBookData
-	err := c.GetAndDecode(ctx, fmt.Sprintf("%spricebook/snapshots/%s/", EPMarket, instrumentID), &out)
+	err := c.GetAndDecode(ctx, fmt.Sprintf("%spricebook/snapshots/%s/", c.ep().Market, instrumentID), &out)
 	if err != nil {
 		return nil, err
 	}
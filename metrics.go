@@ -0,0 +1,109 @@
+package robinhood
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds used by NewLatencyHistogram when
+// no buckets are given.
+var DefaultLatencyBuckets = []time.Duration{
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+}
+
+// A LatencyHistogram records call latencies per endpoint. Use its Middleware
+// with WithMiddleware to populate it.
+type LatencyHistogram struct {
+	buckets []time.Duration
+
+	mu    sync.Mutex
+	byEnd map[string]*HistogramSnapshot
+}
+
+// A HistogramSnapshot is a point-in-time copy of the latencies recorded for
+// one endpoint. Counts[i] is the number of calls that took at most
+// Buckets[i] (and more than Buckets[i-1]); the final element of Counts holds
+// calls slower than every bucket.
+type HistogramSnapshot struct {
+	Buckets []time.Duration
+	Counts  []uint64
+	Count   uint64
+	Sum     time.Duration
+	Errors  uint64
+}
+
+// NewLatencyHistogram returns a histogram with the given bucket upper bounds,
+// or DefaultLatencyBuckets if none are given.
+func NewLatencyHistogram(buckets ...time.Duration) *LatencyHistogram {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	bs := append([]time.Duration(nil), buckets...)
+	sort.Slice(bs, func(i, j int) bool { return bs[i] < bs[j] })
+
+	return &LatencyHistogram{
+		buckets: bs,
+		byEnd:   map[string]*HistogramSnapshot{},
+	}
+}
+
+// Middleware returns a Middleware that records the latency of every call.
+// Calls that fail without a response, or with an error status, are also
+// counted in Errors.
+func (h *LatencyHistogram) Middleware() Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(call *Call) (*http.Response, error) {
+			start := time.Now()
+			res, err := next(call)
+			h.Observe(call.Endpoint, time.Since(start), err != nil || res.StatusCode >= 400)
+			return res, err
+		}
+	}
+}
+
+// Observe records a single call to endpoint.
+func (h *LatencyHistogram) Observe(endpoint string, d time.Duration, failed bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.byEnd[endpoint]
+	if !ok {
+		s = &HistogramSnapshot{
+			Buckets: h.buckets,
+			Counts:  make([]uint64, len(h.buckets)+1),
+		}
+		h.byEnd[endpoint] = s
+	}
+
+	i := sort.Search(len(h.buckets), func(i int) bool { return d <= h.buckets[i] })
+	s.Counts[i]++
+	s.Count++
+	s.Sum += d
+	if failed {
+		s.Errors++
+	}
+}
+
+// Snapshot returns a copy of the histogram for every endpoint that has been
+// called.
+func (h *LatencyHistogram) Snapshot() map[string]HistogramSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	out := make(map[string]HistogramSnapshot, len(h.byEnd))
+	for k, v := range h.byEnd {
+		s := *v
+		s.Counts = append([]uint64(nil), v.Counts...)
+		out[k] = s
+	}
+	return out
+}
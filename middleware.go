@@ -0,0 +1,52 @@
+package robinhood
+
+import "net/http"
+
+// A Call is a single HTTP exchange made by DoAndDecode, as seen by
+// middleware.
+type Call struct {
+	// Request is the outgoing request. Middleware may modify it, e.g. to add
+	// headers, before passing the call on.
+	Request *http.Request
+	// Endpoint is the name of the endpoint being called, as returned by
+	// Endpoints.Name (e.g. "Orders" or "Quotes").
+	Endpoint string
+	// Dest is the value the response body will be decoded into.
+	Dest interface{}
+}
+
+// A RoundTripFunc performs a Call and returns the raw HTTP response. Like an
+// http.RoundTripper, it returns an error only if no response was obtained.
+type RoundTripFunc func(*Call) (*http.Response, error)
+
+// A Middleware wraps the RoundTripFunc that performs a call, so it can observe
+// or change requests and responses, or return a response of its own without
+// calling next.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// WithMiddleware adds middleware around every call made by the Client. The
+// first middleware given is the outermost, so it sees the request first and
+// the response last.
+func WithMiddleware(m ...Middleware) DialOption {
+	return func(c *Client) {
+		c.middleware = append(c.middleware, m...)
+	}
+}
+
+// roundTrip sends the call through the client's middleware chain, subject to
+// the circuit breaker of the host it is finally sent to.
+func (c *Client) roundTrip(call *Call) (*http.Response, error) {
+	rt := RoundTripFunc(func(call *Call) (*http.Response, error) {
+		b := c.breakers.get(call.Request.URL.Host)
+		if err := b.allow(); err != nil {
+			return nil, err
+		}
+		res, err := c.Do(call.Request)
+		b.done(call.Request.Context(), res, err)
+		return res, err
+	})
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		rt = c.middleware[i](rt)
+	}
+	return rt(call)
+}
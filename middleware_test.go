@@ -0,0 +1,157 @@
+package robinhood_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"astuart.co/go-robinhood/v2"
+	"astuart.co/go-robinhood/v2/robinhoodtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewareOrderAndCall(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	var seen []string
+	trace := func(name string) robinhood.Middleware {
+		return func(next robinhood.RoundTripFunc) robinhood.RoundTripFunc {
+			return func(call *robinhood.Call) (*http.Response, error) {
+				seen = append(seen, name+" "+call.Endpoint)
+				call.Request.Header.Set("X-Trace-"+name, "1")
+				return next(call)
+			}
+		}
+	}
+
+	var dest interface{}
+	check := func(next robinhood.RoundTripFunc) robinhood.RoundTripFunc {
+		return func(call *robinhood.Call) (*http.Response, error) {
+			dest = call.Dest
+			assert.Equal(t, "1", call.Request.Header.Get("X-Trace-a"))
+			assert.Equal(t, "1", call.Request.Header.Get("X-Trace-b"))
+			return next(call)
+		}
+	}
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithMiddleware(trace("a"), trace("b")), robinhood.WithMiddleware(check))
+	require.NoError(t, err)
+
+	seen = nil
+	_, err = c.GetQuote(ctx, "SPY")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a Quotes", "b Quotes"}, seen)
+	assert.IsType(t, &struct{ Results []*robinhood.Quote }{}, dest)
+}
+
+func TestMiddlewareFaultInjection(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	fail := func(next robinhood.RoundTripFunc) robinhood.RoundTripFunc {
+		return func(call *robinhood.Call) (*http.Response, error) {
+			if call.Endpoint != "Quotes" {
+				return next(call)
+			}
+			return &http.Response{
+				StatusCode: http.StatusNotFound,
+				Status:     "404 Not Found",
+				Body:       ioutil.NopCloser(strings.NewReader(`{"detail":"Not found."}`)),
+				Request:    call.Request,
+			}, nil
+		}
+	}
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithMiddleware(fail))
+	require.NoError(t, err)
+
+	_, err = c.GetQuote(ctx, "SPY")
+	assert.True(t, errors.Is(err, robinhood.ErrNotFound), "%v", err)
+	for _, r := range s.RequestLog() {
+		assert.NotContains(t, r, "/quotes/")
+	}
+}
+
+func TestLoggingMiddlewareRedacts(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	inst := s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	buf := &bytes.Buffer{}
+	l := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithMiddleware(robinhood.LoggingMiddleware(l)))
+	require.NoError(t, err)
+
+	_, err = c.Order(ctx, &inst, robinhood.OrderOpts{Side: robinhood.Buy, Quantity: robinhood.QuantityFromInt(1)})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("POST", c.Endpoints.Orders, strings.NewReader("username=bob&password=hunter2"))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer sekrit")
+	c.DoAndDecode(ctx, req, &struct{}{})
+
+	out := buf.String()
+	assert.NotContains(t, out, "hunter2")
+	assert.NotContains(t, out, "sekrit")
+	assert.Contains(t, out, "username=bob")
+	assert.Contains(t, out, `"endpoint":"Orders"`)
+	assert.Contains(t, out, `"method":"POST"`)
+
+	tok, err := s.TokenSource().Token()
+	require.NoError(t, err)
+	assert.NotContains(t, out, tok.AccessToken)
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(strings.SplitN(out, "\n", 2)[0]), &line))
+	assert.Equal(t, "robinhood request", line["msg"])
+	assert.Equal(t, float64(http.StatusOK), line["status"])
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	h := robinhood.NewLatencyHistogram()
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithMiddleware(h.Middleware()))
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := c.GetQuote(ctx, "SPY")
+		require.NoError(t, err)
+	}
+	_, err = c.GetInstrument(ctx, c.Endpoints.Instruments+"nope/")
+	require.Error(t, err)
+
+	snap := h.Snapshot()
+	q := snap["Quotes"]
+	assert.Equal(t, uint64(3), q.Count)
+	assert.Equal(t, uint64(0), q.Errors)
+	assert.Len(t, q.Counts, len(robinhood.DefaultLatencyBuckets)+1)
+
+	var total uint64
+	for _, n := range q.Counts {
+		total += n
+	}
+	assert.Equal(t, q.Count, total)
+
+	assert.Equal(t, uint64(1), snap["Instruments"].Errors)
+	assert.Equal(t, uint64(1), snap["Accounts"].Count)
+}
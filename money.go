@@ -0,0 +1,138 @@
+package robinhood
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Money is an exact decimal amount of currency, such as a price, balance or
+// fee. Like the API, it is encoded in JSON as a string; null and empty strings
+// decode as zero. The embedded decimal.Decimal provides arithmetic and
+// comparison.
+type Money struct {
+	decimal.Decimal
+}
+
+// Quantity is an exact decimal number of shares, contracts or coins. It is
+// encoded in JSON like Money.
+type Quantity struct {
+	decimal.Decimal
+}
+
+// NewMoney returns d as Money.
+func NewMoney(d decimal.Decimal) Money {
+	return Money{d}
+}
+
+// MoneyFromFloat returns the Money with the shortest decimal representation
+// of f, so that MoneyFromFloat(0.1) is exactly 0.1.
+func MoneyFromFloat(f float64) Money {
+	return Money{decimal.NewFromFloat(f)}
+}
+
+// ParseMoney parses a decimal string such as "12.34".
+func ParseMoney(s string) (Money, error) {
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q: %w", s, err)
+	}
+	return Money{d}, nil
+}
+
+// MustMoney is like ParseMoney but panics if s is not a valid decimal. It is
+// intended for constants.
+func MustMoney(s string) Money {
+	m, err := ParseMoney(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// NewQuantity returns d as a Quantity.
+func NewQuantity(d decimal.Decimal) Quantity {
+	return Quantity{d}
+}
+
+// QuantityFromInt returns the Quantity n.
+func QuantityFromInt(n int64) Quantity {
+	return Quantity{decimal.NewFromInt(n)}
+}
+
+// QuantityFromFloat returns the Quantity with the shortest decimal
+// representation of f.
+func QuantityFromFloat(f float64) Quantity {
+	return Quantity{decimal.NewFromFloat(f)}
+}
+
+// ParseQuantity parses a decimal string such as "0.5".
+func ParseQuantity(s string) (Quantity, error) {
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return Quantity{}, fmt.Errorf("invalid quantity %q: %w", s, err)
+	}
+	return Quantity{d}, nil
+}
+
+// MustQuantity is like ParseQuantity but panics if s is not a valid decimal.
+// It is intended for constants.
+func MustQuantity(s string) Quantity {
+	q, err := ParseQuantity(s)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// Equal reports whether m and n are the same amount, regardless of how many
+// decimal places either was written with.
+func (m Money) Equal(n Money) bool {
+	return m.Decimal.Equal(n.Decimal)
+}
+
+// Times returns the value of q units at price m.
+func (m Money) Times(q Quantity) Money {
+	return Money{m.Mul(q.Decimal)}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return marshalDecimal(m.Decimal)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *Money) UnmarshalJSON(bs []byte) error {
+	return unmarshalDecimal(&m.Decimal, bs)
+}
+
+// Equal reports whether q and r are the same quantity.
+func (q Quantity) Equal(r Quantity) bool {
+	return q.Decimal.Equal(r.Decimal)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	return marshalDecimal(q.Decimal)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (q *Quantity) UnmarshalJSON(bs []byte) error {
+	return unmarshalDecimal(&q.Decimal, bs)
+}
+
+// marshalDecimal always quotes d, whatever decimal.MarshalJSONWithoutQuotes
+// is set to, since that is what the API expects.
+func marshalDecimal(d decimal.Decimal) ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+func unmarshalDecimal(d *decimal.Decimal, bs []byte) error {
+	bs = bytes.TrimSpace(bs)
+	if string(bs) == "null" || string(bs) == `""` {
+		*d = decimal.Decimal{}
+		return nil
+	}
+	return d.UnmarshalJSON(bs)
+}
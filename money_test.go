@@ -0,0 +1,45 @@
+package robinhood_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"astuart.co/go-robinhood/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoneyJSON(t *testing.T) {
+	var v struct {
+		Price    robinhood.Money    `json:"price"`
+		Cash     robinhood.Money    `json:"cash"`
+		Fees     robinhood.Money    `json:"fees"`
+		Quantity robinhood.Quantity `json:"quantity"`
+		Held     robinhood.Quantity `json:"held"`
+	}
+	err := json.Unmarshal([]byte(`{"price":"0.10","cash":12.5,"fees":null,"quantity":"1.00000000","held":""}`), &v)
+	require.NoError(t, err)
+
+	assert.True(t, v.Price.Equal(robinhood.MustMoney("0.1")))
+	assert.Equal(t, "12.5", v.Cash.String())
+	assert.True(t, v.Fees.IsZero())
+	assert.True(t, v.Quantity.Equal(robinhood.QuantityFromInt(1)))
+	assert.True(t, v.Held.IsZero())
+
+	bs, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"price":"0.1","cash":"12.5","fees":"0","quantity":"1","held":"0"}`, string(bs))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"price":"abc"}`), &v))
+}
+
+func TestMoneyIsExact(t *testing.T) {
+	sum := robinhood.MoneyFromFloat(0.1).Add(robinhood.MoneyFromFloat(0.2).Decimal)
+	assert.Equal(t, "0.3", sum.String())
+
+	cost := robinhood.MustMoney("19.99").Times(robinhood.MustQuantity("3"))
+	assert.True(t, cost.Equal(robinhood.MustMoney("59.97")), "%v", cost)
+
+	_, err := robinhood.ParseQuantity("1.2.3")
+	assert.Error(t, err)
+}
@@ -1,13 +1,16 @@
 package robinhood
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"golang.org/x/oauth2"
 )
@@ -15,71 +18,509 @@ import (
 // DefaultClientID is used by the website.
 const DefaultClientID = "c82SH0WZOsabOXGP2sxqcj34FxkvfnWRZBKlBjFS"
 
+// ChallengeResponseHeader carries the ID of a validated challenge when a
+// login is retried.
+const ChallengeResponseHeader = "X-ROBINHOOD-CHALLENGE-RESPONSE-ID"
+
+// maxLoginSteps bounds the number of login requests a single call to
+// OAuth.Token makes while answering challenges.
+const maxLoginSteps = 4
+
 // OAuth implements oauth2 using the robinhood implementation
 type OAuth struct {
+	// Endpoint, if set, is the full URL of the token endpoint and takes
+	// precedence over Endpoints.
 	Endpoint, ClientID, Username, Password, MFA string
+	// Endpoints overrides the API endpoints used to log in. If nil,
+	// DefaultEndpoints is used.
+	Endpoints *Endpoints
+
+	// DeviceToken identifies this device to Robinhood, which challenges
+	// logins from devices it has not seen before. If empty, a new one is
+	// generated and stored here on the first login; save it and set it on
+	// later logins to avoid being challenged every time.
+	DeviceToken string
+	// ChallengeType is the preferred way of receiving challenge codes,
+	// "sms" or "email". The default is "sms".
+	ChallengeType string
+	// OnChallenge, if set, is called for the code when the login is
+	// challenged, so the login can complete without being started again.
+	// If nil, Token returns the *MFAChallenge instead.
+	OnChallenge ChallengeFunc
+	// RefreshToken, if set, is used to renew the login without the password,
+	// and so without being challenged. Token sets it to the refresh token of
+	// each token it obtains.
+	RefreshToken string
+	// HTTPClient is used to log in, e.g. to go through a proxy or set a
+	// timeout. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// errRefreshChallenged is returned when a refresh is challenged, which only a
+// password login can answer.
+var errRefreshChallenged = errors.New("could not refresh token: challenged")
+
+// refreshRejected reports whether err means the refresh token can no longer
+// be used, as opposed to a transient failure such as a network error, an
+// outage or throttling.
+func refreshRejected(err error) bool {
+	return errors.Is(err, ErrBadCredentials) || errors.Is(err, errRefreshChallenged)
+}
+
+// A Refresher is a TokenSource that can renew a token using its refresh
+// token, such as an OAuth. CredsCacher uses it to renew expired tokens.
+type Refresher interface {
+	Refresh(refreshToken string) (*oauth2.Token, error)
 }
 
-// ErrMFARequired indicates the MFA was required but not provided.
+// A Revoker is a TokenSource that can revoke the tokens it issued, such as an
+// OAuth or a CredsCacher. Client.Logout uses it.
+type Revoker interface {
+	Revoke(ctx context.Context, tok *oauth2.Token) error
+}
+
+// ErrMFARequired indicates the MFA was required but not provided. The
+// *MFAChallenge returned by OAuth.Token matches it with errors.Is.
 var ErrMFARequired = fmt.Errorf("Two Factor Auth code required and not supplied")
 
-// Token implements TokenSource
+// An MFAChallenge is returned by OAuth.Token when the login needs a code that
+// was not supplied.
+type MFAChallenge struct {
+	// ID identifies a device verification challenge, which is answered
+	// separately from the login. It is empty when the code is instead sent
+	// with the login as the mfa_code, as for authenticator apps.
+	ID string `json:"id"`
+	// Type is how the code was sent: "sms", "email" or "app".
+	Type string `json:"type"`
+	// Status is the state of a device verification challenge, e.g.
+	// "issued" or "validated".
+	Status string `json:"status"`
+	// RemainingAttempts is the number of codes that may still be tried, if
+	// known.
+	RemainingAttempts int `json:"remaining_attempts"`
+	// ExpiresAt is when the challenge expires, if known.
+	ExpiresAt Timestamp `json:"expires_at"`
+}
+
+func (ch *MFAChallenge) Error() string {
+	if ch.ID == "" {
+		return fmt.Sprintf("%s (%s)", ErrMFARequired, ch.Type)
+	}
+	return fmt.Sprintf("%s (%s challenge %s)", ErrMFARequired, ch.Type, ch.ID)
+}
+
+// Is makes an *MFAChallenge match ErrMFARequired.
+func (ch *MFAChallenge) Is(target error) bool {
+	return target == ErrMFARequired
+}
+
+// Login failures. A *LoginError matches the one for its kind with errors.Is;
+// throttled logins match ErrThrottled.
+var (
+	ErrBadCredentials = errors.New("bad credentials")
+	ErrAccountLocked  = errors.New("account locked")
+)
+
+// A LoginErrorKind classifies why a login was refused.
+type LoginErrorKind int
+
+// LoginErrorKinds.
+const (
+	// LoginRefused is a refusal that could not be classified further.
+	LoginRefused LoginErrorKind = iota
+	// LoginBadCredentials means the username, password, MFA code or refresh
+	// token was wrong.
+	LoginBadCredentials
+	// LoginAccountLocked means the account is locked or deactivated and
+	// cannot log in until support unlocks it.
+	LoginAccountLocked
+	// LoginThrottled means there were too many login attempts. The
+	// LoginError's RetryAfter says how long to wait, if known.
+	LoginThrottled
+)
+
+func (k LoginErrorKind) String() string {
+	switch k {
+	case LoginRefused:
+		return "refused"
+	case LoginBadCredentials:
+		return "bad credentials"
+	case LoginAccountLocked:
+		return "account locked"
+	case LoginThrottled:
+		return "throttled"
+	}
+	return fmt.Sprintf("LoginErrorKind(%d)", int(k))
+}
+
+// A LoginError is returned by OAuth when the token endpoint refuses a login.
+// It wraps the *APIError with the response.
+type LoginError struct {
+	Kind LoginErrorKind
+	Err  *APIError
+}
+
+// newLoginError classifies an error response from the token endpoint.
+func newLoginError(res *http.Response, body []byte) *LoginError {
+	e := &LoginError{Err: newAPIError(res, body)}
+	e.Err.RetryAfter, _ = retryAfter(res, e.Err)
+
+	msg := strings.ToLower(e.Err.Message())
+	errCode, _ := e.Err.Fields["error"].(string)
+	_, badCode := e.Err.Fields["mfa_code"]
+	switch {
+	case e.Err.Is(ErrThrottled) || strings.Contains(msg, "too many"):
+		e.Kind = LoginThrottled
+	case strings.Contains(msg, "locked") || strings.Contains(msg, "deactivated") ||
+		strings.Contains(msg, "suspended"):
+		e.Kind = LoginAccountLocked
+	case errCode == "invalid_grant" || badCode || res.StatusCode == http.StatusUnauthorized ||
+		strings.Contains(msg, "credentials"):
+		e.Kind = LoginBadCredentials
+	}
+	return e
+}
+
+func (e *LoginError) Error() string {
+	return fmt.Sprintf("login failed (%s): %s", e.Kind, e.Err.Message())
+}
+
+// Unwrap returns the underlying *APIError.
+func (e *LoginError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes a *LoginError match the sentinel error for its kind.
+func (e *LoginError) Is(target error) bool {
+	switch target {
+	case ErrBadCredentials:
+		return e.Kind == LoginBadCredentials
+	case ErrAccountLocked:
+		return e.Kind == LoginAccountLocked
+	case ErrThrottled:
+		return e.Kind == LoginThrottled
+	}
+	return false
+}
+
+// A ChallengeFunc returns the code for a login challenge, for example by
+// prompting the user or reading it from an inbox. Returning an error abandons
+// the login.
+type ChallengeFunc func(ch *MFAChallenge) (code string, err error)
+
+// loginURL returns the token endpoint that should be used for login.
+func (p *OAuth) loginURL() string {
+	if p.Endpoint != "" {
+		return p.Endpoint
+	}
+	return p.ep().Login
+}
+
+// revokeURL returns the endpoint that should be used to revoke tokens. If
+// only Endpoint is set, it is assumed to be next to the token endpoint.
+func (p *OAuth) revokeURL() string {
+	if p.Endpoints == nil && strings.HasSuffix(p.Endpoint, "/token/") {
+		return strings.TrimSuffix(p.Endpoint, "token/") + "revoke_token/"
+	}
+	return p.ep().Revoke
+}
+
+// challengeURL returns the base URL of the challenge endpoints. If only
+// Endpoint is set, it is assumed to be next to the oauth2 endpoints, so that
+// challenge codes are never sent to a different host than the login.
+func (p *OAuth) challengeURL() string {
+	if p.Endpoints == nil && strings.HasSuffix(p.Endpoint, "/token/") {
+		base := strings.TrimSuffix(p.Endpoint, "token/")
+		return strings.TrimSuffix(base, "oauth2/") + "challenge/"
+	}
+	return p.ep().Challenge
+}
+
+func (p *OAuth) clientID() string {
+	if p.ClientID != "" {
+		return p.ClientID
+	}
+	return DefaultClientID
+}
+
+func (p *OAuth) ep() Endpoints {
+	if p.Endpoints != nil {
+		return *p.Endpoints
+	}
+	return DefaultEndpoints
+}
+
+// loginResponse is any of the responses of the token endpoint.
+type loginResponse struct {
+	oauth2.Token
+	ExpiresIn   int           `json:"expires_in"`
+	MFARequired bool          `json:"mfa_required"`
+	MFAType     string        `json:"mfa_type"`
+	Challenge   *MFAChallenge `json:"challenge"`
+}
+
+// Token implements TokenSource. If RefreshToken is set, it is tried first;
+// if the refresh token is rejected, Token logs in with the password instead.
+// Other refresh failures are returned, and RefreshToken is kept for the next
+// try.
 func (p *OAuth) Token() (*oauth2.Token, error) {
-	cliID := p.ClientID
-	if cliID == "" {
-		cliID = DefaultClientID
+	return p.TokenContext(context.Background())
+}
+
+// TokenContext is like Token, but the login requests are made with ctx so
+// they can be cancelled.
+func (p *OAuth) TokenContext(ctx context.Context) (*oauth2.Token, error) {
+	if p.RefreshToken != "" {
+		tok, err := p.refresh(ctx, p.RefreshToken)
+		if err == nil {
+			return tok, nil
+		}
+		if !refreshRejected(err) {
+			return nil, err
+		}
+		p.RefreshToken = ""
+	}
+	return p.passwordGrant(ctx)
+}
+
+// Refresh implements Refresher by renewing a token with the refresh_token
+// grant.
+func (p *OAuth) Refresh(refreshToken string) (*oauth2.Token, error) {
+	return p.refresh(context.Background(), refreshToken)
+}
+
+func (p *OAuth) refresh(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	u, err := p.tokenURL("refresh_token")
+	if err != nil {
+		return nil, err
+	}
+
+	v := url.Values{"refresh_token": []string{refreshToken}}
+	if p.DeviceToken != "" {
+		v.Set("device_token", p.DeviceToken)
+	}
+
+	o, err := p.login(ctx, u, v, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not refresh token")
+	}
+	if o.Challenge != nil || o.MFARequired {
+		return nil, errRefreshChallenged
+	}
+	if o.RefreshToken == "" {
+		o.RefreshToken = refreshToken
+	}
+	return p.issued(o), nil
+}
+
+// Revoke implements Revoker by revoking tok's refresh and access tokens, so
+// that neither can be used again. If tok is nil, RefreshToken is revoked.
+func (p *OAuth) Revoke(ctx context.Context, tok *oauth2.Token) error {
+	refresh, access := p.RefreshToken, ""
+	if tok != nil {
+		access = tok.AccessToken
+		if tok.RefreshToken != "" {
+			refresh = tok.RefreshToken
+		}
+	}
+
+	// The refresh token goes first, as it could be used to obtain new
+	// access tokens.
+	for _, t := range []struct{ token, hint string }{
+		{refresh, "refresh_token"},
+		{access, "access_token"},
+	} {
+		if t.token == "" {
+			continue
+		}
+		v := url.Values{
+			"client_id":       []string{p.clientID()},
+			"token":           []string{t.token},
+			"token_type_hint": []string{t.hint},
+		}
+		res, bs, err := p.postForm(ctx, p.revokeURL(), v, nil)
+		if err != nil {
+			return errors.Wrap(err, "could not revoke token")
+		}
+		if res.StatusCode >= 400 {
+			return newAPIError(res, bs)
+		}
+	}
+
+	if refresh == p.RefreshToken {
+		p.RefreshToken = ""
 	}
+	return nil
+}
 
-	u, _ := url.Parse(EPLogin)
+// tokenURL returns the token endpoint URL for the given grant type.
+func (p *OAuth) tokenURL(grant string) (string, error) {
+	u, err := url.Parse(p.loginURL())
+	if err != nil {
+		return "", errors.Wrap(err, "could not parse login endpoint")
+	}
 	q := u.Query()
 	q.Add("expires_in", fmt.Sprint(24*time.Hour/time.Second))
-	q.Add("client_id", cliID)
-	q.Add("grant_type", "password")
+	q.Add("client_id", p.clientID())
+	q.Add("grant_type", grant)
 	q.Add("scope", "internal")
 	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// issued completes a token returned by the token endpoint and remembers its
+// refresh token.
+func (p *OAuth) issued(o *loginResponse) *oauth2.Token {
+	o.Token.Expiry = time.Now().Add(time.Duration(o.ExpiresIn) * time.Second)
+	if o.RefreshToken != "" {
+		p.RefreshToken = o.RefreshToken
+	}
+	return &o.Token
+}
+
+// passwordGrant logs in with the username and password, answering any
+// challenges.
+func (p *OAuth) passwordGrant(ctx context.Context) (*oauth2.Token, error) {
+	if p.DeviceToken == "" {
+		p.DeviceToken = uuid.New().String()
+	}
+	chType := p.ChallengeType
+	if chType == "" {
+		chType = "sms"
+	}
+
+	u, err := p.tokenURL("password")
+	if err != nil {
+		return nil, err
+	}
 
 	v := url.Values{
-		"username": []string{p.Username},
-		"password": []string{p.Password},
+		"username":       []string{p.Username},
+		"password":       []string{p.Password},
+		"device_token":   []string{p.DeviceToken},
+		"challenge_type": []string{chType},
 	}
 	if p.MFA != "" {
 		v.Add("mfa_code", p.MFA)
 	}
 
-	req, err := http.NewRequest(
-		"POST",
-		u.String(),
-		strings.NewReader(v.Encode()),
-	)
-	if err != nil {
-		return nil, errors.Wrap(err, "could not create request")
+	hdr := http.Header{}
+	for step := 0; step < maxLoginSteps; step++ {
+		o, err := p.login(ctx, u, v, hdr)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case o.Challenge != nil:
+			if p.OnChallenge == nil || hdr.Get(ChallengeResponseHeader) != "" {
+				return nil, o.Challenge
+			}
+			if err := p.respond(ctx, o.Challenge); err != nil {
+				return nil, err
+			}
+			hdr.Set(ChallengeResponseHeader, o.Challenge.ID)
+		case o.MFARequired:
+			ch := &MFAChallenge{Type: o.MFAType}
+			if p.OnChallenge == nil {
+				return nil, ch
+			}
+			code, err := p.OnChallenge(ch)
+			if err != nil {
+				return nil, err
+			}
+			v.Set("mfa_code", code)
+		default:
+			return p.issued(o), nil
+		}
 	}
+	return nil, errors.New("too many login challenges")
+}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("Accept", "application/json")
-	res, err := http.DefaultClient.Do(req)
+// login posts the login form v to the token endpoint u. A refused login is
+// returned as a *LoginError.
+func (p *OAuth) login(ctx context.Context, u string, v url.Values, hdr http.Header) (*loginResponse, error) {
+	res, bs, err := p.postForm(ctx, u, v, hdr)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not post login")
 	}
-	defer res.Body.Close()
 
-	var o struct {
-		oauth2.Token
-		ExpiresIn   int    `json:"expires_in"`
-		MFARequired bool   `json:"mfa_required"`
-		MFAType     string `json:"mfa_type"`
+	var o loginResponse
+	if err := json.Unmarshal(bs, &o); err != nil && res.StatusCode < 400 {
+		return nil, errors.Wrap(err, "could not decode token")
+	}
+	if o.Challenge != nil || o.MFARequired {
+		return &o, nil
+	}
+	if res.StatusCode >= 400 {
+		return nil, newLoginError(res, bs)
+	}
+	if o.AccessToken == "" {
+		return nil, errors.New("login response has no access token")
 	}
+	return &o, nil
+}
 
-	err = json.NewDecoder(res.Body).Decode(&o)
-	if err != nil {
-		return nil, errors.Wrap(err, "could not decode token")
+// respond answers a device verification challenge with codes from
+// OnChallenge until one is accepted or no attempts remain.
+func (p *OAuth) respond(ctx context.Context, ch *MFAChallenge) error {
+	u := p.challengeURL() + url.PathEscape(ch.ID) + "/respond/"
+	for {
+		code, err := p.OnChallenge(ch)
+		if err != nil {
+			return err
+		}
+
+		res, bs, err := p.postForm(ctx, u, url.Values{"response": []string{code}}, nil)
+		if err != nil {
+			return errors.Wrap(err, "could not respond to challenge")
+		}
+
+		var o struct {
+			MFAChallenge
+			Challenge *MFAChallenge `json:"challenge"`
+		}
+		json.Unmarshal(bs, &o)
+
+		switch {
+		case res.StatusCode < 400 && o.Status == "validated":
+			return nil
+		case o.Challenge != nil && o.Challenge.RemainingAttempts > 0:
+			*ch = *o.Challenge
+		case res.StatusCode >= 400:
+			return newAPIError(res, bs)
+		default:
+			return fmt.Errorf("challenge %s not validated: %q", ch.ID, o.Status)
+		}
 	}
+}
 
-	if o.MFARequired {
-		return nil, ErrMFARequired
+// postForm posts the form v to u with the HTTPClient and returns the response
+// and its body.
+func (p *OAuth) postForm(ctx context.Context, u string, v url.Values, hdr http.Header) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", u, strings.NewReader(v.Encode()))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not create request")
+	}
+	for k := range hdr {
+		req.Header.Set(k, hdr.Get(k))
 	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
 
-	o.Token.Expiry = time.Now().Add(time.Duration(o.ExpiresIn) * time.Second)
+	cli := p.HTTPClient
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+	res, err := cli.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
 
-	return &o.Token, nil
+	bs, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, bs, nil
 }
@@ -11,12 +11,16 @@ import (
 
 // OptionsOrderOpts encapsulates common Options order choices
 type OptionsOrderOpts struct {
-	Quantity    float64
-	Price       float64
+	Quantity    Quantity
+	Price       Money
 	Direction   OptionDirection
 	TimeInForce TimeInForce
 	Type        OrderType
 	Side        OrderSide
+
+	// Account, if set, is the account the order is placed in instead of the
+	// Client's account.
+	Account *Account
 }
 
 // optionInput is the input object to the RobinHood API
@@ -26,8 +30,8 @@ type optionInput struct {
 	Legs                   []Leg           `json:"legs"`
 	OverrideDayTradeChecks bool            `json:"override_day_trade_checks"`
 	OverrideDtbpChecks     bool            `json:"override_dtbp_checks"`
-	Price                  float64         `json:"price,string"`
-	Quantity               float64         `json:"quantity,string"`
+	Price                  Money           `json:"price"`
+	Quantity               Quantity        `json:"quantity"`
 	RefID                  string          `json:"ref_id"`
 	TimeInForce            TimeInForce     `json:"time_in_force"`
 	Trigger                string          `json:"trigger"`
@@ -39,7 +43,7 @@ type optionInput struct {
 type Leg struct {
 	Option         string    `json:"option"`
 	PositionEffect string    `json:"position_effect"`
-	RatioQuantity  float64   `json:"ratio_quantity,string"`
+	RatioQuantity  Quantity  `json:"ratio_quantity"`
 	Side           OrderSide `json:"side"`
 }
 
@@ -47,13 +51,18 @@ type Leg struct {
 // context.Context will cancel the _http request_, never the order itself if it
 // has already been created.
 func (c *Client) OrderOptions(ctx context.Context, q *OptionInstrument, o OptionsOrderOpts) (json.RawMessage, error) {
+	acct, err := c.accountFor(ctx, o.Account)
+	if err != nil {
+		return nil, err
+	}
+
 	b := optionInput{
-		Account:     c.Account.URL,
+		Account:     acct.URL,
 		Direction:   o.Direction,
 		TimeInForce: o.TimeInForce,
 		Legs: []Leg{{
 			Option:         q.URL,
-			RatioQuantity:  1,
+			RatioQuantity:  QuantityFromInt(1),
 			Side:           o.Side,
 			PositionEffect: "open",
 		}},
@@ -73,7 +82,7 @@ func (c *Client) OrderOptions(ctx context.Context, q *OptionInstrument, o Option
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", EPOptions+"orders/", bytes.NewReader(bs))
+	req, err := http.NewRequest("POST", c.ep().Options+"orders/", bytes.NewReader(bs))
 	if err != nil {
 		return nil, err
 	}
@@ -87,14 +96,8 @@ func (c *Client) OrderOptions(ctx context.Context, q *OptionInstrument, o Option
 	return out, nil
 }
 
-// GetOptionsOrders returns all outstanding options orders
-func (c *Client) GetOptionsOrders(ctx context.Context) (json.RawMessage, error) {
-	var o json.RawMessage
-	err := c.GetAndDecode(ctx, EPOptions+"orders/", &o)
-	if err != nil {
-		return nil, err
-	}
-
-	return o, nil
-
+// GetOptionsOrders returns all options orders, from every page of results, as
+// raw JSON.
+func (c *Client) GetOptionsOrders(ctx context.Context) ([]json.RawMessage, error) {
+	return c.IterOptionsOrders().All(ctx)
 }
@@ -7,8 +7,6 @@ import (
 	"net/url"
 	"strings"
 	"time"
-
-	"github.com/hashicorp/go-multierror"
 )
 
 const dateFormat = "2006-01-02"
@@ -37,59 +35,68 @@ func (d Date) MarshalJSON() ([]byte, error) {
 	return []byte("\"" + d.String() + "\""), nil
 }
 
-// UnmarshalJSON implements json.Unmarshaler
+// UnmarshalJSON implements json.Unmarshaler. It accepts a date or a full
+// timestamp, of which only the date is kept; null and "" decode as the zero
+// Date.
 func (d *Date) UnmarshalJSON(bs []byte) error {
-	t, err := time.Parse(dateFormat, strings.Trim(strings.TrimSpace(string(bs)), "\""))
+	t, err := parseTime(bs)
 	if err != nil {
 		return err
 	}
+	if !t.IsZero() {
+		t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
 	d.Time = t
 	return nil
 }
 
-// GetOptionChains returns options for the given instruments
+// GetOptionChains returns options for the given instruments. If the client
+// has a Cache, it is consulted first.
 func (c *Client) GetOptionChains(ctx context.Context, is ...*Instrument) ([]*OptionChain, error) {
 	s := []string{}
 	for _, inst := range is {
 		s = append(s, inst.ID)
 	}
-
-	var res struct{ Results []*OptionChain }
-
-	err := c.GetAndDecode(ctx, EPOptions+"chains/?equity_instrument_ids="+strings.Join(s, ","), &res)
-	if err != nil {
-		return nil, err
-	}
-
-	for i := range res.Results {
-		res.Results[i].c = c
+	ids := strings.Join(s, ",")
+
+	chs, err := cached(c, CacheOptionChains, ids, func() ([]*OptionChain, error) {
+		return newIterator[*OptionChain](c, c.ep().Options+"chains/?equity_instrument_ids="+ids, nil).All(ctx)
+	})
+	for _, ch := range chs {
+		if ch != nil {
+			ch.c = c
+		}
 	}
-
-	return res.Results, nil
+	return chs, err
 }
 
 // OptionChain represents the data the RobinHood API holds behind options chains
 type OptionChain struct {
 	CanOpenPosition       bool                   `json:"can_open_position"`
 	CashComponent         interface{}            `json:"cash_component"`
-	ExpirationDates       []string               `json:"expiration_dates"`
+	ExpirationDates       []Date                 `json:"expiration_dates"`
 	ID                    string                 `json:"id"`
 	MinTicks              MinTicks               `json:"min_ticks"`
 	Symbol                string                 `json:"symbol"`
-	TradeValueMultiplier  float64                `json:"trade_value_multiplier,string"`
+	TradeValueMultiplier  Quantity               `json:"trade_value_multiplier"`
 	UnderlyingInstruments []UnderlyingInstrument `json:"underlying_instruments"`
 
 	c *Client
 }
 
+// A Pager holds the links to neighbouring pages of a paginated list endpoint.
+// Use an Iterator to walk every page.
 type Pager struct {
 	Next, Previous string
 }
 
+// HasMore reports whether there is a next page.
 func (p Pager) HasMore() bool {
 	return p.Next != ""
 }
 
+// GetNext retrieves the next page into out, or returns io.EOF if there is no
+// next page.
 func (p *Pager) GetNext(ctx context.Context, c *Client, out interface{}) error {
 	if p.Next == "" {
 		return io.EOF
@@ -98,53 +105,34 @@ func (p *Pager) GetNext(ctx context.Context, c *Client, out interface{}) error {
 	return c.GetAndDecode(ctx, p.Next, out)
 }
 
-// GetInstrument returns a list of option-typed instruments given a list of
-// expiration dates for a given trade type. The request will continue until the
-// provided context is cancelled. This is done to mimic the way the web UI
-// fetches many, many options instruments repeatedly, since I haven't yet
-// figured out how/when they decide to stop.
+// GetInstrument returns the active, tradable option instruments of the given
+// trade type expiring on date, fetching every page; see IterInstruments.
 func (o *OptionChain) GetInstrument(ctx context.Context, tradeType string, date Date) ([]*OptionInstrument, error) {
+	return o.IterInstruments(tradeType, date).All(ctx)
+}
+
+// IterInstruments iterates over the active, tradable option instruments of the
+// given trade type expiring on date.
+func (o *OptionChain) IterInstruments(tradeType string, date Date) *Iterator[*OptionInstrument] {
 	u := fmt.Sprintf(
 		"%sinstruments/?chain_id=%s&expiration_dates=%s&state=active&tradability=tradable&type=%s",
-		EPOptions,
+		o.c.ep().Options,
 		o.ID,
 		date,
 		tradeType,
 	)
-
-	var rs []*OptionInstrument
-	var out struct {
-		Results []*OptionInstrument
-		Pager
-	}
-	err := o.c.GetAndDecode(ctx, u, &out)
-	if err != nil {
-		return nil, err
-	}
-
-	rs = append(rs, out.Results...)
-
-	for out.HasMore() {
-		select {
-		case <-ctx.Done():
-			return rs, ctx.Err()
-		default:
-		}
-
-		err := out.GetNext(ctx, o.c, &out)
-		if err != nil {
-			return rs, err
+	return newIterator(o.c, u, func(i **OptionInstrument) {
+		if *i != nil {
+			(*i).c = o.c
 		}
-		rs = append(rs, out.Results...)
-	}
-	return rs, nil
+	})
 }
 
 // MinTicks probably is important.
 type MinTicks struct {
-	AboveTick   float64 `json:"above_tick,string"`
-	BelowTick   float64 `json:"below_tick,string"`
-	CutoffPrice float64 `json:"cutoff_price,string"`
+	AboveTick   Money `json:"above_tick"`
+	BelowTick   Money `json:"below_tick"`
+	CutoffPrice Money `json:"cutoff_price"`
 }
 
 // UnderlyingInstrument is the type that represents a link from an option back
@@ -157,20 +145,20 @@ type UnderlyingInstrument struct {
 
 // An OptionInstrument can have a quote
 type OptionInstrument struct {
-	ChainID        string   `json:"chain_id"`
-	ChainSymbol    string   `json:"chain_symbol"`
-	CreatedAt      string   `json:"created_at"`
-	ExpirationDate Date     `json:"expiration_date"`
-	ID             string   `json:"id"`
-	IssueDate      string   `json:"issue_date"`
-	MinTicks       MinTicks `json:"min_ticks"`
-	RHSTradability string   `json:"rhs_tradability"`
-	State          string   `json:"state"`
-	StrikePrice    float64  `json:"strike_price,string"`
-	Tradability    string   `json:"tradability"`
-	Type           string   `json:"type"`
-	UpdatedAt      string   `json:"updated_at"`
-	URL            string   `json:"url"`
+	ChainID        string    `json:"chain_id"`
+	ChainSymbol    string    `json:"chain_symbol"`
+	CreatedAt      Timestamp `json:"created_at"`
+	ExpirationDate Date      `json:"expiration_date"`
+	ID             string    `json:"id"`
+	IssueDate      Date      `json:"issue_date"`
+	MinTicks       MinTicks  `json:"min_ticks"`
+	RHSTradability string    `json:"rhs_tradability"`
+	State          string    `json:"state"`
+	StrikePrice    Money     `json:"strike_price"`
+	Tradability    string    `json:"tradability"`
+	Type           string    `json:"type"`
+	UpdatedAt      Timestamp `json:"updated_at"`
+	URL            string    `json:"url"`
 
 	c *Client
 }
@@ -178,26 +166,26 @@ type OptionInstrument struct {
 // MarketData is the current pricing data and greeks for a given option at a
 // given time.
 type MarketData struct {
-	AdjustedMarkPrice   float64 `json:"adjusted_mark_price,string"`
-	AskPrice            float64 `json:"ask_price,string"`
+	AdjustedMarkPrice   Money   `json:"adjusted_mark_price"`
+	AskPrice            Money   `json:"ask_price"`
 	AskSize             int     `json:"ask_size"`
-	BidPrice            float64 `json:"bid_price,string"`
+	BidPrice            Money   `json:"bid_price"`
 	BidSize             int     `json:"bid_size"`
-	BreakEvenPrice      float64 `json:"break_even_price,string"`
+	BreakEvenPrice      Money   `json:"break_even_price"`
 	ChanceOfProfitLong  float64 `json:"chance_of_profit_long,string"`
 	ChanceOfProfitShort float64 `json:"chance_of_profit_short,string"`
 	Delta               float64 `json:"delta,string"`
 	Gamma               float64 `json:"gamma,string"`
-	HighPrice           float64 `json:"high_price,string"`
+	HighPrice           Money   `json:"high_price"`
 	ImpliedVolatility   string  `json:"implied_volatility"`
 	Instrument          string  `json:"instrument"`
-	LastTradePrice      float64 `json:"last_trade_price,string"`
+	LastTradePrice      Money   `json:"last_trade_price"`
 	LastTradeSize       int     `json:"last_trade_size"`
-	LowPrice            float64 `json:"low_price,string"`
-	MarkPrice           float64 `json:"mark_price,string"`
+	LowPrice            Money   `json:"low_price"`
+	MarkPrice           Money   `json:"mark_price"`
 	OpenInterest        int     `json:"open_interest"`
 	PreviousCloseDate   Date    `json:"previous_close_date"`
-	PreviousClosePrice  float64 `json:"previous_close_price,string"`
+	PreviousClosePrice  Money   `json:"previous_close_price"`
 	Rho                 string  `json:"rho"`
 	Theta               string  `json:"theta"`
 	Vega                string  `json:"vega"`
@@ -215,50 +203,27 @@ func OIsForDate(os []*OptionInstrument, d Date) []*OptionInstrument {
 	return out
 }
 
-// MarketData returns market data for all the listed Option instruments
+// MarketData returns market data for all the listed Option instruments, in
+// the order given. Instruments are requested in batches according to the
+// client's BatchOptions; if some fail, the rest are returned along with a
+// *BatchError.
 func (c *Client) MarketData(ctx context.Context, opts ...*OptionInstrument) ([]*MarketData, error) {
 	is := make([]string, len(opts))
-
 	for i, o := range opts {
 		is[i] = o.URL
 	}
 
-	u, err := url.Parse(EPOptionQuote)
+	u, err := url.Parse(c.ep().OptionQuote)
 	if err != nil {
-		return nil, shameWrap(err, "couldn't parse URL const EPOptionQuote")
-	}
-
-	// Number of instruments to request at once
-	num := 30
-	// Number of requests this will require to be made
-	n := len(is) / num
-	if len(is)%num != 0 {
-		n++
+		return nil, shameWrap(err, "couldn't parse option quote endpoint URL")
 	}
 
-	rs := []*MarketData{}
-
-	for i := 0; i < n; i++ {
-		end := (i+1)*num + 1
-		if end > len(is) {
-			end = len(is)
-		}
-
-		q := url.Values{"instruments": []string{strings.Join(is[i*num:end], ",")}}
-
-		u.RawQuery = q.Encode()
+	return batch(ctx, c, is, func(ctx context.Context, chunk []string) ([]*MarketData, error) {
+		u := *u
+		u.RawQuery = url.Values{"instruments": []string{strings.Join(chunk, ",")}}.Encode()
 
 		var r struct{ Results []*MarketData }
-		if e := c.GetAndDecode(ctx, u.String(), &r); err != nil {
-			err = multierror.Append(err, e)
-			continue
-		}
-		for _, res := range r.Results {
-			if res != nil {
-				rs = append(rs, res)
-			}
-		}
-	}
-
-	return rs, err
+		err := c.GetAndDecode(ctx, u.String(), &r)
+		return r.Results, err
+	})
 }
@@ -19,8 +19,9 @@ func (o OrderSide) MarshalJSON() ([]byte, error) {
 	return []byte("\"" + strings.ToLower(o.String()) + "\""), nil
 }
 
-//go:generate stringer -type OrderSide
 // Buy/Sell
+//
+//go:generate stringer -type OrderSide
 const (
 	Sell OrderSide = iota + 1
 	Buy
@@ -34,8 +35,9 @@ func (o OrderType) MarshalJSON() ([]byte, error) {
 	return []byte(fmt.Sprintf("%q", strings.ToLower(o.String()))), nil
 }
 
-//go:generate stringer -type OrderType
 // Well-known order types. Default is Market.
+//
+//go:generate stringer -type OrderType
 const (
 	Market OrderType = iota
 	Limit
@@ -45,11 +47,15 @@ const (
 type OrderOpts struct {
 	Side          OrderSide
 	Type          OrderType
-	Quantity      uint64
-	Price         float64
+	Quantity      Quantity
+	Price         Money
 	TimeInForce   TimeInForce
 	ExtendedHours bool
 	Stop, Force   bool
+
+	// Account, if set, is the account the order is placed in instead of the
+	// Client's account.
+	Account *Account
 }
 
 type apiOrder struct {
@@ -59,9 +65,9 @@ type apiOrder struct {
 	Type          string    `json:"type,omitempty"`
 	TimeInForce   string    `json:"time_in_force,omitempty"`
 	Trigger       string    `json:"trigger,omitempty"`
-	Price         float64   `json:"price,omitempty"`
-	StopPrice     float64   `json:"stop_price,omitempty"`
-	Quantity      uint64    `json:"quantity,omitempty"`
+	Price         *Money    `json:"price,omitempty"`
+	StopPrice     *Money    `json:"stop_price,omitempty"`
+	Quantity      Quantity  `json:"quantity"`
 	Side          OrderSide `json:"side,omitempty"`
 	ExtendedHours bool      `json:"extended_hours,omitempty"`
 
@@ -73,8 +79,13 @@ type apiOrder struct {
 // context cancels only the _http request_ and not any orders that may have
 // been created regardless of the cancellation.
 func (c *Client) Order(ctx context.Context, i *Instrument, o OrderOpts) (*OrderOutput, error) {
+	acct, err := c.accountFor(ctx, o.Account)
+	if err != nil {
+		return nil, err
+	}
+
 	a := apiOrder{
-		Account:       c.Account.URL,
+		Account:       acct.URL,
 		Instrument:    i.URL,
 		Symbol:        i.Symbol,
 		Type:          strings.ToLower(o.Type.String()),
@@ -82,12 +93,14 @@ func (c *Client) Order(ctx context.Context, i *Instrument, o OrderOpts) (*OrderO
 		Quantity:      o.Quantity,
 		Side:          o.Side,
 		ExtendedHours: o.ExtendedHours,
-		Price:         o.Price,
 		Trigger:       "immediate",
 	}
 
+	if !o.Price.IsZero() {
+		a.Price = &o.Price
+	}
 	if o.Stop {
-		a.StopPrice = o.Price
+		a.StopPrice = &o.Price
 		a.Trigger = "stop"
 	}
 
@@ -96,7 +109,7 @@ func (c *Client) Order(ctx context.Context, i *Instrument, o OrderOpts) (*OrderO
 		return nil, err
 	}
 
-	post, err := http.NewRequest("POST", EPOrders, bytes.NewReader(bs))
+	post, err := http.NewRequest("POST", c.ep().Orders, bytes.NewReader(bs))
 	if err != nil {
 		return nil, fmt.Errorf("error creating POST http.Request: %v", err)
 	}
@@ -117,24 +130,24 @@ func (c *Client) Order(ctx context.Context, i *Instrument, o OrderOpts) (*OrderO
 type OrderOutput struct {
 	Meta
 	Account                string        `json:"account"`
-	AveragePrice           float64       `json:"average_price,string"`
+	AveragePrice           Money         `json:"average_price"`
 	CancelURL              string        `json:"cancel"`
-	CumulativeQuantity     string        `json:"cumulative_quantity"`
+	CumulativeQuantity     Quantity      `json:"cumulative_quantity"`
 	Executions             []interface{} `json:"executions"`
 	ExtendedHours          bool          `json:"extended_hours"`
-	Fees                   string        `json:"fees"`
+	Fees                   Money         `json:"fees"`
 	ID                     string        `json:"id"`
 	Instrument             string        `json:"instrument"`
-	LastTransactionAt      string        `json:"last_transaction_at"`
+	LastTransactionAt      Timestamp     `json:"last_transaction_at"`
 	OverrideDayTradeChecks bool          `json:"override_day_trade_checks"`
 	OverrideDtbpChecks     bool          `json:"override_dtbp_checks"`
 	Position               string        `json:"position"`
-	Price                  float64       `json:"price,string"`
-	Quantity               string        `json:"quantity"`
+	Price                  Money         `json:"price"`
+	Quantity               Quantity      `json:"quantity"`
 	RejectReason           string        `json:"reject_reason"`
 	Side                   string        `json:"side"`
 	State                  string        `json:"state"`
-	StopPrice              float64       `json:"stop_price,string"`
+	StopPrice              Money         `json:"stop_price"`
 	TimeInForce            string        `json:"time_in_force"`
 	Trigger                string        `json:"trigger"`
 	Type                   string        `json:"type"`
@@ -171,7 +184,7 @@ func (c *Client) RecentOrders(ctx context.Context) ([]OrderOutput, error) {
 	var o struct {
 		Results []OrderOutput
 	}
-	err := c.GetAndDecode(ctx, EPOrders, &o)
+	err := c.GetAndDecode(ctx, c.ep().Orders, &o)
 	if err != nil {
 		return o.Results, err
 	}
@@ -183,37 +196,10 @@ func (c *Client) RecentOrders(ctx context.Context) ([]OrderOutput, error) {
 	return o.Results, nil
 }
 
-// AllOrders returns all orders made by this client.
+// AllOrders returns all orders made by this client. Each page is retried
+// according to the client's RetryPolicy, so a transient failure resumes from
+// the page that failed. If a page still fails, the orders retrieved so far are
+// returned along with the error.
 func (c *Client) AllOrders(ctx context.Context) ([]OrderOutput, error) {
-	var o struct {
-		Results []OrderOutput
-	}
-
-	url := EPOrders
-	for {
-		select {
-		case <-ctx.Done():
-			return o.Results, ctx.Err()
-		default:
-		}
-
-		var tmp struct {
-			Results []OrderOutput
-			Next    string
-		}
-		err := c.GetAndDecode(ctx, url, &tmp)
-
-		if err != nil {
-			return o.Results, err
-		}
-
-		url = tmp.Next
-		o.Results = append(o.Results, tmp.Results...)
-
-		if url == "" {
-			break
-		}
-	}
-
-	return o.Results, nil
+	return c.IterOrders().All(ctx)
 }
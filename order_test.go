@@ -0,0 +1,175 @@
+package robinhood_test
+
+import (
+	"context"
+	"testing"
+
+	"astuart.co/go-robinhood/v2"
+	"astuart.co/go-robinhood/v2/robinhoodtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderFill(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	inst := s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx)
+	require.NoError(t, err)
+
+	asrt := assert.New(t)
+
+	o, err := c.Order(ctx, &inst, robinhood.OrderOpts{
+		Side:     robinhood.Buy,
+		Type:     robinhood.Market,
+		Quantity: robinhood.QuantityFromInt(10),
+	})
+	require.NoError(t, err)
+	asrt.Equal(robinhoodtest.StateUnconfirmed, o.State)
+
+	asrt.NoError(o.Update(ctx))
+	asrt.Equal(robinhoodtest.StateConfirmed, o.State)
+
+	asrt.NoError(o.Update(ctx))
+	asrt.Equal(robinhoodtest.StateFilled, o.State)
+	asrt.Equal("100", o.AveragePrice.String())
+
+	ps, err := c.GetPositions(ctx)
+	asrt.NoError(err)
+	if asrt.Len(ps, 1) {
+		asrt.Equal("10", ps[0].Quantity.String())
+		asrt.Equal(inst.URL, ps[0].Instrument)
+	}
+
+	a, _ := s.Account(c.Account.AccountNumber)
+	asrt.Equal("9000", a.BuyingPower.String())
+
+	asrt.Error(o.Cancel(ctx), "filled orders cannot be cancelled")
+}
+
+func TestOrderCancel(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	inst := s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx)
+	require.NoError(t, err)
+
+	asrt := assert.New(t)
+
+	// A limit buy below the market rests until cancelled.
+	o, err := c.Order(ctx, &inst, robinhood.OrderOpts{
+		Side:     robinhood.Buy,
+		Type:     robinhood.Limit,
+		Price:    robinhood.MustMoney("90"),
+		Quantity: robinhood.QuantityFromInt(1),
+	})
+	require.NoError(t, err)
+
+	asrt.NoError(o.Update(ctx))
+	asrt.NoError(o.Update(ctx))
+	asrt.Equal(robinhoodtest.StateConfirmed, o.State)
+
+	asrt.NoError(o.Cancel(ctx))
+	asrt.NoError(o.Update(ctx))
+	asrt.Equal(robinhoodtest.StateCancelled, o.State)
+}
+
+func TestOrderInsufficientBuyingPower(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	inst := s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx)
+	require.NoError(t, err)
+
+	_, err = c.Order(ctx, &inst, robinhood.OrderOpts{
+		Side:     robinhood.Buy,
+		Type:     robinhood.Market,
+		Quantity: robinhood.QuantityFromInt(1000),
+	})
+	assert.Error(t, err)
+}
+
+func TestAllOrders(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.PageSize = 2
+	inst := s.AddStock("SPY", robinhood.MustMoney("1"))
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err := c.Order(ctx, &inst, robinhood.OrderOpts{Side: robinhood.Buy, Quantity: robinhood.QuantityFromInt(1)})
+		require.NoError(t, err)
+	}
+
+	os, err := c.AllOrders(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, os, 5)
+}
+
+func TestCryptoOrder(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddCurrencyPair("BTC", robinhood.MustMoney("10000"))
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx)
+	require.NoError(t, err)
+
+	asrt := assert.New(t)
+
+	p, err := c.GetCryptoInstrument(ctx, "BTC")
+	require.NoError(t, err)
+
+	o, err := c.CryptoOrder(ctx, *p, robinhood.CryptoOrderOpts{
+		Side:            robinhood.Buy,
+		Type:            robinhood.Market,
+		AmountInDollars: robinhood.MustMoney("100"),
+		Price:           robinhood.MustMoney("10000"),
+	})
+	require.NoError(t, err)
+
+	got, err := c.GetCryptoOrder(ctx, o.ID)
+	asrt.NoError(err)
+	asrt.Equal(robinhoodtest.StateConfirmed, got.State)
+
+	got, err = c.GetCryptoOrder(ctx, o.ID)
+	asrt.NoError(err)
+	asrt.Equal(robinhoodtest.StateFilled, got.State)
+
+	hs, err := c.GetCryptoPositions(ctx)
+	asrt.NoError(err)
+	if asrt.Len(hs, 1) {
+		asrt.Equal("BTC", hs[0].Currency.Code)
+		asrt.Equal("0.01", hs[0].Quantity.String())
+	}
+}
+
+func TestCryptoOrderNeedsQuantity(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddCurrencyPair("BTC", robinhood.MustMoney("10000"))
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx)
+	require.NoError(t, err)
+
+	p, err := c.GetCryptoInstrument(ctx, "BTC")
+	require.NoError(t, err)
+
+	_, err = c.CryptoOrder(ctx, *p, robinhood.CryptoOrderOpts{
+		Side:            robinhood.Buy,
+		Type:            robinhood.Market,
+		AmountInDollars: robinhood.MustMoney("100"),
+	})
+	assert.Error(t, err)
+	assert.Zero(t, countRequests(s, "POST /nummus/orders/"))
+}
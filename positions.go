@@ -7,32 +7,32 @@ import (
 
 type Position struct {
 	Meta
-	Account                 string  `json:"account"`
-	AverageBuyPrice         float64 `json:"average_buy_price,string"`
-	Instrument              string  `json:"instrument"`
-	IntradayAverageBuyPrice float64 `json:"intraday_average_buy_price,string"`
-	IntradayQuantity        float64 `json:"intraday_quantity,string"`
-	Quantity                float64 `json:"quantity,string"`
-	SharesHeldForBuys       float64 `json:"shares_held_for_buys,string"`
-	SharesHeldForSells      float64 `json:"shares_held_for_sells,string"`
+	Account                 string   `json:"account"`
+	AverageBuyPrice         Money    `json:"average_buy_price"`
+	Instrument              string   `json:"instrument"`
+	IntradayAverageBuyPrice Money    `json:"intraday_average_buy_price"`
+	IntradayQuantity        Quantity `json:"intraday_quantity"`
+	Quantity                Quantity `json:"quantity"`
+	SharesHeldForBuys       Quantity `json:"shares_held_for_buys"`
+	SharesHeldForSells      Quantity `json:"shares_held_for_sells"`
 }
 
 // CryptoCurrency represents a sub object listed in CryptoPosition
 type CryptoCurrency struct {
-	BrandColor string  `json:"brand_color"`
-	Code       string  `json:"code"`
-	ID         string  `json:"id"`
-	Increment  float64 `json:"increment,string"`
-	Name       string  `json:"name"`
-	Type       string  `json:"type"`
+	BrandColor string   `json:"brand_color"`
+	Code       string   `json:"code"`
+	ID         string   `json:"id"`
+	Increment  Quantity `json:"increment"`
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
 }
 
 // CostBases represents the actual cost the robinhood user paid for asset
 type CostBases struct {
-	CurrencyID      string  `json:"currency_id"`
-	DirectCostBasis float64 `json:"direct_cost_basis,string"`
-	DirectQuantity  float64 `json:"direct_quantity,string"`
-	ID              string  `json:"id"`
+	CurrencyID      string   `json:"currency_id"`
+	DirectCostBasis Money    `json:"direct_cost_basis"`
+	DirectQuantity  Quantity `json:"direct_quantity"`
+	ID              string   `json:"id"`
 }
 
 // CryptoPosition returns all crypto position associated with an account
@@ -41,39 +41,39 @@ type CryptoPosition struct {
 	AccountID           string         `json:"account_id"`
 	ID                  string         `json:"id"`
 	Currency            CryptoCurrency `json:"currency"`
-	Cost                []CostBases      `json:"cost_bases"`
-	Quantity            float64        `json:"quantity,string"`
-	QuantityAvailable   float64        `json:"quantity_available,string"`
-	QuantityHeldForBuy  float64        `json:"quantity_held_for_buy,string"`
-	QuantityHeldForSell float64        `json:"quantity_held_for_sell,string"`
+	Cost                []CostBases    `json:"cost_bases"`
+	Quantity            Quantity       `json:"quantity"`
+	QuantityAvailable   Quantity       `json:"quantity_available"`
+	QuantityHeldForBuy  Quantity       `json:"quantity_held_for_buy"`
+	QuantityHeldForSell Quantity       `json:"quantity_held_for_sell"`
 }
 type OptionPostion struct {
 	Chain                    string        `json:"chain"`
-	AverageOpenPrice         string        `json:"average_open_price"`
+	AverageOpenPrice         Money         `json:"average_open_price"`
 	Symbol                   string        `json:"symbol"`
-	Quantity                 string        `json:"quantity"`
+	Quantity                 Quantity      `json:"quantity"`
 	Direction                string        `json:"direction"`
 	IntradayDirection        string        `json:"intraday_direction"`
-	TradeValueMultiplier     string        `json:"trade_value_multiplier"`
+	TradeValueMultiplier     Quantity      `json:"trade_value_multiplier"`
 	Account                  string        `json:"account"`
 	Strategy                 string        `json:"strategy"`
 	Legs                     []LegPosition `json:"legs"`
-	IntradayQuantity         string        `json:"intraday_quantity"`
-	UpdatedAt                string        `json:"updated_at"`
+	IntradayQuantity         Quantity      `json:"intraday_quantity"`
+	UpdatedAt                Timestamp     `json:"updated_at"`
 	Id                       string        `json:"id"`
-	IntradayAverageOpenPrice string        `json:"intraday_average_open_price"`
-	CreatedAt                string        `json:"created_at"`
+	IntradayAverageOpenPrice Money         `json:"intraday_average_open_price"`
+	CreatedAt                Timestamp     `json:"created_at"`
 }
 
 type LegPosition struct {
-	Id             string `json:"id"`
-	Position       string `json:"position"`
-	PositionType   string `json:"position_type"`
-	Option         string `json:"option"`
-	RatioQuantity  string `json:"ratio_quantity"`
-	ExpirationDate string `json:"expiration_date"`
-	StrikePrice    string `json:"strike_price"`
-	OptionType     string `json:"option_type"`
+	Id             string   `json:"id"`
+	Position       string   `json:"position"`
+	PositionType   string   `json:"position_type"`
+	Option         string   `json:"option"`
+	RatioQuantity  Quantity `json:"ratio_quantity"`
+	ExpirationDate Date     `json:"expiration_date"`
+	StrikePrice    Money    `json:"strike_price"`
+	OptionType     string   `json:"option_type"`
 }
 
 type Unknown interface{}
@@ -92,6 +92,8 @@ func (c *Client) GetPositions(ctx context.Context) ([]Position, error) {
 // endpoint.
 type PositionParams struct {
 	NonZero bool
+	// Account, if set, limits the positions to those held in that account.
+	Account *Account
 }
 
 // Encode returns the query string associated with the requested parameters
@@ -100,6 +102,9 @@ func (p PositionParams) encode() string {
 	if p.NonZero {
 		v.Set("nonzero", "true")
 	}
+	if p.Account != nil {
+		v.Set("account_number", p.Account.AccountNumber)
+	}
 	return v.Encode()
 }
 
@@ -107,37 +112,17 @@ func (p PositionParams) encode() string {
 // passes the encoded PositionsParams object along to the RobinHood API as part
 // of the query string.
 func (c *Client) GetPositionsParams(ctx context.Context, p PositionParams) ([]Position, error) {
-	u, err := url.Parse(EPPositions)
-	if err != nil {
-		return nil, err
-	}
-	u.RawQuery = p.encode()
-
-	var r struct{ Results []Position }
-	return r.Results, c.GetAndDecode(ctx, u.String(), &r)
+	return c.IterPositions(p).All(ctx)
 }
 
 // GetPositionsParams returns all the positions associated with a count, but
 // passes the encoded PositionsParams object along to the RobinHood API as part
 // of the query string.
 func (c *Client) GetOptionPositionsParams(ctx context.Context, p PositionParams) ([]OptionPostion, error) {
-	u, err := url.Parse(EPOptions + "aggregate_positions/")
-	if err != nil {
-		return nil, err
-	}
-	u.RawQuery = p.encode()
-
-	var r struct{ Results []OptionPostion }
-	return r.Results, c.GetAndDecode(ctx, u.String(), &r)
+	return c.IterOptionPositions(p).All(ctx)
 }
 
 // GetCryptoPositions returns all positions associated with the account
 func (c *Client) GetCryptoPositions(ctx context.Context) ([]CryptoPosition, error) {
-	var r struct{ Results []CryptoPosition }
-	err := c.GetAndDecode(ctx, EPCryptoHoldings, &r)
-	if err != nil {
-		return nil, err
-	}
-
-	return r.Results, err
+	return c.IterCryptoPositions().All(ctx)
 }
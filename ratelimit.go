@@ -0,0 +1,207 @@
+package robinhood
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultThrottleRetries is the number of times an idempotent request that
+// was throttled by the API is retried before the error is returned.
+const DefaultThrottleRetries = 3
+
+// throttleBackoff is the wait before retrying a throttled or unavailable
+// request when the API does not say how long to wait. It doubles with each
+// retry.
+const throttleBackoff = time.Second
+
+// maxRetryAfter is the longest wait asked for by the API that is honored. A
+// request asked to wait longer is not retried, and its error is returned.
+const maxRetryAfter = time.Minute
+
+// A RateLimit describes a client-side token bucket: requests are allowed at an
+// average of Rate per second, with bursts of up to Burst requests.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// WithRateLimit limits the rate of requests the Client makes to host, which
+// may be given as a bare host name (e.g. "nummus.robinhood.com") or as a URL
+// such as one of the Endpoints bases. Each host has an independent limit.
+func WithRateLimit(host string, l RateLimit) DialOption {
+	if strings.Contains(host, "://") {
+		if u, err := url.Parse(host); err == nil {
+			host = u.Host
+		}
+	}
+
+	return func(c *Client) {
+		if c.limiters == nil {
+			c.limiters = map[string]*tokenBucket{}
+		}
+		c.limiters[host] = newTokenBucket(l)
+	}
+}
+
+// WithThrottleRetries sets how many times a throttled (429) or unavailable
+// (503) idempotent request is retried after waiting for the time the API asks
+// for, or with exponential backoff from one second if it does not say. Zero
+// disables retries. Requests asked to wait more than a minute are not
+// retried. Non-idempotent requests, such as placing orders,
+// are never retried.
+func WithThrottleRetries(n int) DialOption {
+	return func(c *Client) {
+		c.throttleRetries = n
+	}
+}
+
+// tokenBucket is a simple token bucket rate limiter.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(l RateLimit) *tokenBucket {
+	if l.Burst < 1 {
+		l.Burst = 1
+	}
+	return &tokenBucket{
+		rate:   l.Rate,
+		burst:  float64(l.Burst),
+		tokens: float64(l.Burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait before
+// using it.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rate <= 0 {
+		return 0
+	}
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns a token taken by reserve that will not be used.
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens++
+}
+
+// Wait blocks until a request may be made or the context is done.
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	d := b.reserve()
+	if d == 0 {
+		return nil
+	}
+	if err := sleep(ctx, d); err != nil {
+		b.cancel()
+		return err
+	}
+	return nil
+}
+
+// waitForHost blocks until the rate limit for host, if any, allows another
+// request.
+func (c *Client) waitForHost(ctx context.Context, host string) error {
+	b, ok := c.limiters[host]
+	if !ok {
+		return nil
+	}
+	return b.Wait(ctx)
+}
+
+// sleep waits for d or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+var throttleDetail = regexp.MustCompile(`[Ee]xpected available in (\d+) seconds?`)
+
+// throttled reports whether status is a throttled or unavailable response,
+// which may be retried after waiting.
+func throttled(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// retryAfter returns how long the API asked the client to wait before trying
+// again, for throttled and unavailable responses, and whether it said so at
+// all.
+func retryAfter(res *http.Response, e *APIError) (time.Duration, bool) {
+	if !throttled(res.StatusCode) {
+		return 0, false
+	}
+
+	if h := res.Header.Get("Retry-After"); h != "" {
+		if s, err := strconv.Atoi(h); err == nil && s >= 0 {
+			return time.Duration(s) * time.Second, true
+		}
+		if t, err := http.ParseTime(h); err == nil {
+			if d := time.Until(t); d > 0 {
+				return d, true
+			}
+			return 0, true
+		}
+	}
+
+	if m := throttleDetail.FindStringSubmatch(e.Message()); m != nil {
+		s, _ := strconv.Atoi(m[1])
+		return time.Duration(s) * time.Second, true
+	}
+
+	return 0, false
+}
+
+// throttleWait returns how long to wait before the given retry, starting at
+// 0, of a request that failed with e.
+func throttleWait(e *APIError, retry int) time.Duration {
+	if e.hinted {
+		return e.RetryAfter
+	}
+	return throttleBackoff << uint(retry)
+}
+
+// idempotent reports whether a request may safely be sent more than once.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
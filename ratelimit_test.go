@@ -0,0 +1,124 @@
+package robinhood_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"astuart.co/go-robinhood/v2"
+	"astuart.co/go-robinhood/v2/robinhoodtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottledGetIsRetried(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx)
+	require.NoError(t, err)
+
+	s.Throttle(2, 0)
+	qs, err := c.GetQuote(ctx, "SPY")
+	assert.NoError(t, err)
+	assert.Len(t, qs, 1)
+
+	s.Throttle(5, 0)
+	_, err = c.GetQuote(ctx, "SPY")
+	assert.True(t, errors.Is(err, robinhood.ErrThrottled), "%v", err)
+}
+
+func TestThrottledOrderIsNotRetried(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	inst := s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx)
+	require.NoError(t, err)
+
+	s.Throttle(1, 0)
+	_, err = c.Order(ctx, &inst, robinhood.OrderOpts{Side: robinhood.Buy, Quantity: robinhood.QuantityFromInt(1)})
+	assert.True(t, errors.Is(err, robinhood.ErrThrottled), "%v", err)
+
+	os, err := c.AllOrders(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, os)
+}
+
+func TestThrottleWaitRespectsContext(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	c, err := s.Dial(context.Background())
+	require.NoError(t, err)
+
+	s.Throttle(1, 30)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = c.GetQuote(ctx, "SPY")
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
+
+func TestRateLimit(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithRateLimit(s.URL, robinhood.RateLimit{Rate: 50, Burst: 1}))
+	require.NoError(t, err)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		_, err := c.GetQuote(ctx, "SPY")
+		require.NoError(t, err)
+	}
+	assert.True(t, time.Since(start) >= 80*time.Millisecond, "took %s", time.Since(start))
+}
+
+func TestUnavailableWithoutRetryAfterBacksOff(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	c, err := s.Dial(context.Background())
+	require.NoError(t, err)
+
+	s.FailRequests(1000, http.StatusServiceUnavailable, "/quotes/")
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = c.GetQuote(ctx, "SPY")
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, time.Since(start) >= time.Second, "took %s", time.Since(start))
+	assert.Equal(t, 2, countRequests(s, "/quotes/"))
+}
+
+func TestLongRetryAfterIsNotWaitedFor(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx)
+	require.NoError(t, err)
+
+	s.Throttle(1, 3600)
+	start := time.Now()
+	_, err = c.GetQuote(ctx, "SPY")
+	var apiErr *robinhood.APIError
+	if assert.True(t, errors.As(err, &apiErr), "%v", err) {
+		assert.Equal(t, time.Hour, apiErr.RetryAfter)
+	}
+	assert.True(t, time.Since(start) < time.Second, "took %s", time.Since(start))
+}
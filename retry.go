@@ -0,0 +1,108 @@
+package robinhood
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+// A RetryPolicy controls how GET requests made through GetAndDecode are
+// retried after transient network errors or retryable error statuses. Writes
+// such as orders are never retried. Throttled or unavailable responses with a
+// status the policy retries count against MaxAttempts rather than the
+// Client's throttle retries, and the policy waits at least as long as the API
+// asks.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first. A
+	// value of 1 or less disables retries.
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry. Each following retry
+	// waits twice as long as the previous one, up to MaxDelay.
+	BaseDelay, MaxDelay time.Duration
+	// Jitter is the fraction, between 0 and 1, of each delay that is
+	// randomized to avoid many clients retrying in lockstep.
+	Jitter float64
+	// RetryableStatus reports whether an error response with the given
+	// status code should be retried. If nil, DefaultRetryableStatus is used.
+	RetryableStatus func(status int) bool
+}
+
+// DefaultRetryPolicy is a reasonable policy for interactive use.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   250 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+	Jitter:      0.5,
+}
+
+// WithRetryPolicy makes the Client retry GET requests according to p.
+func WithRetryPolicy(p RetryPolicy) DialOption {
+	return func(c *Client) {
+		c.retry = &p
+	}
+}
+
+// DefaultRetryableStatus reports whether status is a server error that is
+// usually transient.
+func DefaultRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Backoff returns the delay before the given retry, starting at 1.
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	d := float64(p.BaseDelay) * math.Pow(2, float64(retry-1))
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		d -= d * p.Jitter * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+// shouldRetry reports whether the error from the given attempt, starting at
+// 1, should be retried.
+func (p RetryPolicy) shouldRetry(ctx context.Context, attempt int, err error) bool {
+	if err == nil || attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return p.retriesStatus(apiErr.StatusCode) && apiErr.RetryAfter <= maxRetryAfter
+	}
+
+	return isTransient(err)
+}
+
+// retriesStatus reports whether p retries error responses with status. A nil
+// policy retries nothing.
+func (p *RetryPolicy) retriesStatus(status int) bool {
+	if p == nil || p.MaxAttempts <= 1 {
+		return false
+	}
+	retryable := p.RetryableStatus
+	if retryable == nil {
+		retryable = DefaultRetryableStatus
+	}
+	return retryable(status)
+}
+
+// isTransient reports whether err looks like a network failure that may
+// succeed if tried again.
+func isTransient(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
@@ -0,0 +1,113 @@
+package robinhood_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"astuart.co/go-robinhood/v2"
+	"astuart.co/go-robinhood/v2/robinhoodtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fastRetries = robinhood.RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Millisecond,
+	MaxDelay:    5 * time.Millisecond,
+}
+
+func TestRetryPolicy(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithRetryPolicy(fastRetries))
+	require.NoError(t, err)
+
+	s.FailRequests(2, http.StatusBadGateway, "/quotes/")
+	qs, err := c.GetQuote(ctx, "SPY")
+	assert.NoError(t, err)
+	assert.Len(t, qs, 1)
+
+	s.FailRequests(3, http.StatusBadGateway, "/quotes/")
+	_, err = c.GetQuote(ctx, "SPY")
+	var apiErr *robinhood.APIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+	}
+
+	// Client errors are not retried.
+	s.FailRequests(1, http.StatusBadRequest, "/quotes/")
+	_, err = c.GetQuote(ctx, "SPY")
+	assert.Error(t, err)
+	_, err = c.GetQuote(ctx, "SPY")
+	assert.NoError(t, err)
+}
+
+func TestAllOrdersResumesFailedPage(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.PageSize = 2
+	inst := s.AddStock("SPY", robinhood.MustMoney("1"))
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithRetryPolicy(fastRetries))
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err := c.Order(ctx, &inst, robinhood.OrderOpts{Side: robinhood.Buy, Quantity: robinhood.QuantityFromInt(1)})
+		require.NoError(t, err)
+	}
+
+	s.FailRequests(2, http.StatusServiceUnavailable, "cursor=2")
+	os, err := c.AllOrders(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, os, 5)
+
+	first := 0
+	for _, r := range s.RequestLog() {
+		if strings.HasSuffix(r, "/orders/") && strings.HasPrefix(r, "GET") {
+			first++
+		}
+	}
+	assert.Equal(t, 1, first, "first page should only be fetched once")
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := robinhood.RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	assert.Equal(t, 100*time.Millisecond, p.Backoff(1))
+	assert.Equal(t, 200*time.Millisecond, p.Backoff(2))
+	assert.Equal(t, 400*time.Millisecond, p.Backoff(3))
+	assert.Equal(t, time.Second, p.Backoff(10))
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.Backoff(2)
+		assert.True(t, d > 100*time.Millisecond && d <= 200*time.Millisecond, "%s", d)
+	}
+}
+
+func TestRetryPolicySharesThrottleBudget(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	ctx := context.Background()
+	p := fastRetries
+	p.MaxAttempts = 4
+	c, err := s.Dial(ctx, robinhood.WithRetryPolicy(p))
+	require.NoError(t, err)
+
+	s.FailRequests(1000, http.StatusServiceUnavailable, "/quotes/")
+	_, err = c.GetQuote(ctx, "SPY")
+	var apiErr *robinhood.APIError
+	if assert.True(t, errors.As(err, &apiErr), "%v", err) {
+		assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	}
+	assert.Equal(t, 4, countRequests(s, "/quotes/"))
+}
@@ -0,0 +1,289 @@
+package robinhoodtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"astuart.co/go-robinhood/v2"
+	"github.com/google/uuid"
+)
+
+type pair struct {
+	robinhood.CryptoCurrencyPair
+	price robinhood.Money
+}
+
+type cryptoOrder struct {
+	out robinhood.CryptoOrderOutput
+
+	pairID    string
+	side, typ string
+	qty       robinhood.Quantity
+	price     robinhood.Money
+}
+
+type cryptoOrderInput struct {
+	AccountID      string             `json:"account_id"`
+	CurrencyPairID string             `json:"currency_pair_id"`
+	Price          robinhood.Money    `json:"price"`
+	RefID          string             `json:"ref_id"`
+	Side           string             `json:"side"`
+	TimeInForce    string             `json:"time_in_force"`
+	Quantity       robinhood.Quantity `json:"quantity"`
+	Type           string             `json:"type"`
+}
+
+// AddCurrencyPair adds a tradable USD currency pair for the crypto asset with
+// the given code (e.g. "BTC") and current price, and returns it.
+func (s *Server) AddCurrencyPair(code string, price robinhood.Money) robinhood.CryptoCurrencyPair {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := &pair{
+		CryptoCurrencyPair: robinhood.CryptoCurrencyPair{
+			ID:                     uuid.New().String(),
+			Name:                   code + " to US Dollar",
+			Symbol:                 code + "-USD",
+			Tradability:            "tradable",
+			MaxOrderSize:           robinhood.QuantityFromInt(1000000),
+			MinOrderSize:           robinhood.MustQuantity("0.000001"),
+			MinOrderPriceIncrement: robinhood.MustMoney("0.01"),
+			CyrptoAssetCurrency: robinhood.AssetCurrency{
+				Code:      code,
+				ID:        uuid.New().String(),
+				Increment: robinhood.MustQuantity("0.00000001"),
+				Name:      code,
+			},
+			CrytoQuoteCurrency: robinhood.QuoteCurrency{
+				Code:      "USD",
+				ID:        uuid.New().String(),
+				Increment: robinhood.MustMoney("0.01"),
+				Name:      "US Dollar",
+				Type:      "fiat",
+			},
+		},
+		price: price,
+	}
+	s.pairs[p.ID] = p
+	return p.CryptoCurrencyPair
+}
+
+// SetCryptoPrice changes the price at which orders for the currency pair with
+// the given ID fill.
+func (s *Server) SetCryptoPrice(pairID string, price robinhood.Money) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p, ok := s.pairs[pairID]; ok {
+		p.price = price
+	}
+}
+
+// CryptoOrder returns the current state of the crypto order with the given
+// ID.
+func (s *Server) CryptoOrder(id string) (robinhood.CryptoOrderOutput, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.cryptoOrds[id]
+	if !ok {
+		return robinhood.CryptoOrderOutput{}, false
+	}
+	return o.out, true
+}
+
+func (s *Server) listPairs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ps := make([]robinhood.CryptoCurrencyPair, 0, len(s.pairs))
+	for _, p := range s.pairs {
+		ps = append(ps, p.CryptoCurrencyPair)
+	}
+	s.writePage(w, r, ps)
+}
+
+func (s *Server) listHoldings(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hs := make([]*robinhood.CryptoPosition, 0, len(s.holdings))
+	for _, h := range s.holdings {
+		hs = append(hs, h)
+	}
+	s.writePage(w, r, hs)
+}
+
+func (s *Server) createCryptoOrder(w http.ResponseWriter, r *http.Request) {
+	var in cryptoOrderInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found := false
+	for _, a := range s.cryptoAccounts {
+		found = found || a.ID == in.AccountID
+	}
+	if !found {
+		writeJSON(w, http.StatusBadRequest, map[string][]string{"account_id": {"Invalid account."}})
+		return
+	}
+
+	p, ok := s.pairs[in.CurrencyPairID]
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string][]string{"currency_pair_id": {"Invalid currency pair."}})
+		return
+	}
+
+	if in.Quantity.Sign() <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string][]string{"quantity": {"Ensure this value is greater than 0."}})
+		return
+	}
+
+	if in.Side != "buy" && in.Side != "sell" {
+		writeJSON(w, http.StatusBadRequest, map[string][]string{"side": {fmt.Sprintf("%q is not a valid choice.", in.Side)}})
+		return
+	}
+
+	if in.Side == "sell" {
+		if h, ok := s.holdings[p.CyrptoAssetCurrency.Code]; !ok || h.QuantityAvailable.LessThan(in.Quantity.Decimal) {
+			writeError(w, http.StatusBadRequest, DetailInsufficientShares)
+			return
+		}
+	}
+
+	id := uuid.New().String()
+	now := time.Now()
+	u := s.URL + nummusPrefix + "orders/" + id + "/"
+	o := &cryptoOrder{
+		pairID: p.ID,
+		side:   in.Side,
+		typ:    in.Type,
+		qty:    in.Quantity,
+		price:  in.Price,
+		out: robinhood.CryptoOrderOutput{
+			Meta:               robinhood.Meta{CreatedAt: now, UpdatedAt: now, URL: u},
+			Account:            in.AccountID,
+			CancelURL:          u + "cancel/",
+			CumulativeQuantity: robinhood.Quantity{},
+			CurrencyPairID:     p.ID,
+			Executions:         []robinhood.Execution{},
+			ID:                 id,
+			LastTransactionAt:  robinhood.Timestamp{Time: now},
+			Price:              in.Price,
+			Quantity:           in.Quantity,
+			Side:               in.Side,
+			State:              StateUnconfirmed,
+			TimeInForce:        in.TimeInForce,
+			Type:               in.Type,
+		},
+	}
+	s.cryptoOrds[id] = o
+
+	writeJSON(w, http.StatusCreated, o.out)
+}
+
+func (s *Server) getCryptoOrder(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.cryptoOrds[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not found.")
+		return
+	}
+	s.advanceCrypto(o)
+	writeJSON(w, http.StatusOK, o.out)
+}
+
+func (s *Server) cancelCryptoOrder(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed.")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.cryptoOrds[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not found.")
+		return
+	}
+	if !cancellable(o.out.State) {
+		writeError(w, http.StatusBadRequest, DetailNotCancellable)
+		return
+	}
+
+	o.out.State = StateCancelled
+	o.out.CancelURL = ""
+	o.out.UpdatedAt = time.Now()
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+// advanceCrypto moves an open crypto order one step through its lifecycle.
+func (s *Server) advanceCrypto(o *cryptoOrder) {
+	switch o.out.State {
+	case StateUnconfirmed:
+		o.out.State = StateConfirmed
+		o.out.UpdatedAt = time.Now()
+	case StateConfirmed:
+		px := s.pairs[o.pairID].price
+		if o.typ == "market" ||
+			(o.side == "buy" && px.LessThanOrEqual(o.price.Decimal)) ||
+			(o.side == "sell" && px.GreaterThanOrEqual(o.price.Decimal)) {
+			s.fillCrypto(o, px)
+		}
+	}
+}
+
+func (s *Server) fillCrypto(o *cryptoOrder, px robinhood.Money) {
+	now := time.Now()
+	o.out.Executions = append(o.out.Executions, robinhood.Execution{
+		EffectivePrice: px,
+		ID:             uuid.New().String(),
+		Quantity:       o.qty,
+		Timestamp:      robinhood.Timestamp{Time: now},
+	})
+	o.out.State = StateFilled
+	o.out.CancelURL = ""
+	o.out.AveragePrice = px
+	o.out.CumulativeQuantity = o.qty
+	o.out.UpdatedAt = now
+	o.out.LastTransactionAt = robinhood.Timestamp{Time: now}
+
+	p := s.pairs[o.pairID]
+	code := p.CyrptoAssetCurrency.Code
+	h, ok := s.holdings[code]
+	if !ok {
+		h = &robinhood.CryptoPosition{
+			Meta:      robinhood.Meta{CreatedAt: now},
+			AccountID: o.out.Account,
+			ID:        uuid.New().String(),
+			Currency: robinhood.CryptoCurrency{
+				Code:      code,
+				ID:        p.CyrptoAssetCurrency.ID,
+				Increment: p.CyrptoAssetCurrency.Increment,
+				Name:      p.CyrptoAssetCurrency.Name,
+				Type:      "cryptocurrency",
+			},
+		}
+		s.holdings[code] = h
+	}
+	h.UpdatedAt = now
+
+	switch o.side {
+	case "buy":
+		h.Quantity = robinhood.NewQuantity(h.Quantity.Add(o.qty.Decimal))
+		h.QuantityAvailable = robinhood.NewQuantity(h.QuantityAvailable.Add(o.qty.Decimal))
+	case "sell":
+		h.Quantity = robinhood.NewQuantity(h.Quantity.Sub(o.qty.Decimal))
+		h.QuantityAvailable = robinhood.NewQuantity(h.QuantityAvailable.Sub(o.qty.Decimal))
+	}
+}
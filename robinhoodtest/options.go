@@ -0,0 +1,153 @@
+package robinhoodtest
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"astuart.co/go-robinhood/v2"
+	"github.com/google/uuid"
+)
+
+// AddOption adds an active, tradable option instrument on an equity that was
+// previously added with AddStock, creating the equity's option chain if needed.
+// typ is "call" or "put". The returned instrument is served by the options
+// instruments endpoint, and md by the options market data endpoint.
+func (s *Server) AddOption(symbol, typ string, strike robinhood.Money, exp robinhood.Date, md robinhood.MarketData) (robinhood.OptionInstrument, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, ok := s.symbols[symbol]
+	if !ok {
+		return robinhood.OptionInstrument{}, fmt.Errorf("no instrument for symbol %q", symbol)
+	}
+	inst := s.instruments[id]
+
+	var ch *robinhood.OptionChain
+	for _, c := range s.chains {
+		if c.Symbol == symbol {
+			ch = c
+		}
+	}
+	if ch == nil {
+		ch = &robinhood.OptionChain{
+			CanOpenPosition:      true,
+			ID:                   uuid.New().String(),
+			Symbol:               symbol,
+			TradeValueMultiplier: robinhood.QuantityFromInt(100),
+			MinTicks: robinhood.MinTicks{
+				AboveTick:   robinhood.MustMoney("0.05"),
+				BelowTick:   robinhood.MustMoney("0.01"),
+				CutoffPrice: robinhood.MustMoney("3"),
+			},
+			UnderlyingInstruments: []robinhood.UnderlyingInstrument{{
+				ID:         uuid.New().String(),
+				Instrument: inst.URL,
+				Quantity:   100,
+			}},
+		}
+		s.chains[ch.ID] = ch
+		inst.TradableChainID = ch.ID
+	}
+
+	found := false
+	for _, d := range ch.ExpirationDates {
+		found = found || d.Equal(exp.Time)
+	}
+	if !found {
+		ch.ExpirationDates = append(ch.ExpirationDates, exp)
+	}
+
+	now := robinhood.Timestamp{Time: time.Now()}
+	oid := uuid.New().String()
+	o := &robinhood.OptionInstrument{
+		ChainID:        ch.ID,
+		ChainSymbol:    symbol,
+		CreatedAt:      now,
+		ExpirationDate: exp,
+		ID:             oid,
+		IssueDate:      robinhood.Date{Time: now.Truncate(24 * time.Hour)},
+		MinTicks:       ch.MinTicks,
+		RHSTradability: "tradable",
+		State:          "active",
+		StrikePrice:    strike,
+		Tradability:    "tradable",
+		Type:           typ,
+		UpdatedAt:      now,
+		URL:            s.URL + apiPrefix + "options/instruments/" + oid + "/",
+	}
+	s.options = append(s.options, o)
+
+	md.Instrument = o.URL
+	s.marketData[o.URL] = &md
+
+	return *o, nil
+}
+
+func (s *Server) listChains(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := splitList(r.URL.Query().Get("equity_instrument_ids"))
+	cs := []*robinhood.OptionChain{}
+	for _, id := range ids {
+		inst, ok := s.instruments[id]
+		if !ok || inst.TradableChainID == "" {
+			continue
+		}
+		cs = append(cs, s.chains[inst.TradableChainID])
+	}
+	s.writePage(w, r, cs)
+}
+
+func (s *Server) listOptionInstruments(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := r.URL.Query()
+	dates := splitList(q.Get("expiration_dates"))
+
+	os := []*robinhood.OptionInstrument{}
+	for _, o := range s.options {
+		if c := q.Get("chain_id"); c != "" && o.ChainID != c {
+			continue
+		}
+		if t := q.Get("type"); t != "" && o.Type != t {
+			continue
+		}
+		if st := q.Get("state"); st != "" && o.State != st {
+			continue
+		}
+		if len(dates) > 0 && !contains(dates, o.ExpirationDate.String()) {
+			continue
+		}
+		os = append(os, o)
+	}
+	s.writePage(w, r, os)
+}
+
+func (s *Server) listMarketData(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	us, ok := s.batchParam(w, r, "instruments")
+	if !ok {
+		return
+	}
+
+	// Unknown instruments are returned as null in order.
+	mds := make([]*robinhood.MarketData, len(us))
+	for i, u := range us {
+		mds[i] = s.marketData[u]
+	}
+	writeJSON(w, http.StatusOK, results(mds))
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,369 @@
+package robinhoodtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"astuart.co/go-robinhood/v2"
+	"github.com/google/uuid"
+)
+
+// Order states used by the fake server. New orders start unconfirmed, are
+// confirmed the next time they are fetched, and fill once they are confirmed
+// and marketable against the current quote.
+const (
+	StateUnconfirmed = "unconfirmed"
+	StateConfirmed   = "confirmed"
+	StateFilled      = "filled"
+	StateCancelled   = "cancelled"
+	StateRejected    = "rejected"
+)
+
+// Error details returned by the fake server when an order is refused.
+const (
+	DetailInsufficientBuyingPower = "You do not have enough buying power to place this order."
+	DetailInsufficientShares      = "Not enough shares to sell."
+	DetailNotCancellable          = "This order cannot be cancelled."
+	DetailMarketClosed            = "Orders cannot be placed while the market is closed."
+)
+
+type order struct {
+	out robinhood.OrderOutput
+
+	symbol     string
+	side, typ  string
+	qty        robinhood.Quantity
+	price      robinhood.Money
+	accountURL string
+	instrURL   string
+}
+
+type orderInput struct {
+	Account       string             `json:"account"`
+	Instrument    string             `json:"instrument"`
+	Symbol        string             `json:"symbol"`
+	Type          string             `json:"type"`
+	TimeInForce   string             `json:"time_in_force"`
+	Trigger       string             `json:"trigger"`
+	Side          string             `json:"side"`
+	Price         robinhood.Money    `json:"price"`
+	StopPrice     robinhood.Money    `json:"stop_price"`
+	Quantity      robinhood.Quantity `json:"quantity"`
+	ExtendedHours bool               `json:"extended_hours"`
+}
+
+// Order returns the current state of the equity order with the given ID.
+func (s *Server) Order(id string) (robinhood.OrderOutput, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[id]
+	if !ok {
+		return robinhood.OrderOutput{}, false
+	}
+	return o.out, true
+}
+
+// FillOrder fills the open equity order with the given ID at price,
+// regardless of the current quote.
+func (s *Server) FillOrder(id string, price robinhood.Money) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[id]
+	if !ok {
+		return fmt.Errorf("no order %q", id)
+	}
+	if !cancellable(o.out.State) {
+		return fmt.Errorf("order %q is %s", id, o.out.State)
+	}
+	s.fill(o, price)
+	return nil
+}
+
+// RejectOrder rejects the open equity order with the given ID.
+func (s *Server) RejectOrder(id, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[id]
+	if !ok {
+		return fmt.Errorf("no order %q", id)
+	}
+	if !cancellable(o.out.State) {
+		return fmt.Errorf("order %q is %s", id, o.out.State)
+	}
+	o.out.State = StateRejected
+	o.out.RejectReason = reason
+	o.out.CancelURL = ""
+	o.touch()
+	return nil
+}
+
+// SetPosition sets the quantity of shares of the given instrument held in the
+// first account.
+func (s *Server) SetPosition(i robinhood.Instrument, quantity robinhood.Quantity, averageBuyPrice robinhood.Money) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.position(s.accounts[0].URL, i.URL)
+	p.Quantity = quantity
+	p.AverageBuyPrice = averageBuyPrice
+}
+
+// SetAccountPosition sets the quantity of shares of the given instrument held
+// in the account with the given number.
+func (s *Server) SetAccountPosition(number string, i robinhood.Instrument, quantity robinhood.Quantity, averageBuyPrice robinhood.Money) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a := s.accountByNumber(number)
+	if a == nil {
+		return fmt.Errorf("no account %q", number)
+	}
+	p := s.position(a.URL, i.URL)
+	p.Quantity = quantity
+	p.AverageBuyPrice = averageBuyPrice
+	return nil
+}
+
+func (s *Server) position(acctURL, instURL string) *robinhood.Position {
+	k := acctURL + " " + instURL
+	p, ok := s.positions[k]
+	if !ok {
+		now := time.Now()
+		p = &robinhood.Position{
+			Meta: robinhood.Meta{
+				CreatedAt: now,
+				UpdatedAt: now,
+				URL:       s.URL + apiPrefix + "positions/" + uuid.New().String() + "/",
+			},
+			Account:    acctURL,
+			Instrument: instURL,
+		}
+		s.positions[k] = p
+		s.positionKeys = append(s.positionKeys, k)
+	}
+	return p
+}
+
+func (s *Server) createOrder(w http.ResponseWriter, r *http.Request) {
+	var in orderInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a := s.accountByURL(in.Account)
+	if a == nil {
+		writeJSON(w, http.StatusBadRequest, map[string][]string{"account": {"Invalid account."}})
+		return
+	}
+
+	if s.marketClosed {
+		writeJSON(w, http.StatusBadRequest, map[string][]string{"non_field_errors": {DetailMarketClosed}})
+		return
+	}
+
+	inst := s.instrumentByURL(in.Instrument)
+	if inst == nil || inst.Symbol != in.Symbol {
+		writeJSON(w, http.StatusBadRequest, map[string][]string{"instrument": {"Invalid instrument."}})
+		return
+	}
+
+	if in.Quantity.Sign() <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string][]string{"quantity": {"Ensure this value is greater than 0."}})
+		return
+	}
+
+	if in.Side != "buy" && in.Side != "sell" {
+		writeJSON(w, http.StatusBadRequest, map[string][]string{"side": {fmt.Sprintf("%q is not a valid choice.", in.Side)}})
+		return
+	}
+
+	qty, price := in.Quantity, in.Price
+	if in.Type == "market" || price.IsZero() {
+		price = s.quotes[inst.Symbol].LastTradePrice
+	}
+
+	switch in.Side {
+	case "buy":
+		if price.Times(qty).GreaterThan(a.BuyingPower.Decimal) {
+			writeError(w, http.StatusBadRequest, DetailInsufficientBuyingPower)
+			return
+		}
+	case "sell":
+		if p, ok := s.positions[a.URL+" "+inst.URL]; !ok || p.Quantity.LessThan(qty.Decimal) {
+			writeError(w, http.StatusBadRequest, DetailInsufficientShares)
+			return
+		}
+	}
+
+	id := uuid.New().String()
+	now := time.Now()
+	u := s.URL + apiPrefix + "orders/" + id + "/"
+	o := &order{
+		symbol:     inst.Symbol,
+		side:       in.Side,
+		typ:        in.Type,
+		qty:        qty,
+		price:      in.Price,
+		accountURL: a.URL,
+		instrURL:   inst.URL,
+		out: robinhood.OrderOutput{
+			Meta:               robinhood.Meta{CreatedAt: now, UpdatedAt: now, URL: u},
+			Account:            a.URL,
+			CancelURL:          u + "cancel/",
+			CumulativeQuantity: robinhood.Quantity{},
+			Executions:         []interface{}{},
+			ExtendedHours:      in.ExtendedHours,
+			Fees:               robinhood.Money{},
+			ID:                 id,
+			Instrument:         inst.URL,
+			LastTransactionAt:  robinhood.Timestamp{Time: now},
+			Position:           s.position(a.URL, inst.URL).URL,
+			Price:              in.Price,
+			Quantity:           qty,
+			Side:               in.Side,
+			State:              StateUnconfirmed,
+			StopPrice:          in.StopPrice,
+			TimeInForce:        in.TimeInForce,
+			Trigger:            in.Trigger,
+			Type:               in.Type,
+		},
+	}
+	s.orders[id] = o
+	s.orderIDs = append(s.orderIDs, id)
+
+	writeJSON(w, http.StatusCreated, o.out)
+}
+
+func (s *Server) listOrders(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Newest first, as the real API returns them.
+	os := make([]robinhood.OrderOutput, 0, len(s.orderIDs))
+	for i := len(s.orderIDs) - 1; i >= 0; i-- {
+		os = append(os, s.orders[s.orderIDs[i]].out)
+	}
+	s.writePage(w, r, os)
+}
+
+func (s *Server) getOrder(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not found.")
+		return
+	}
+	s.advance(o)
+	writeJSON(w, http.StatusOK, o.out)
+}
+
+func (s *Server) cancelOrder(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed.")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not found.")
+		return
+	}
+	if !cancellable(o.out.State) {
+		writeError(w, http.StatusBadRequest, DetailNotCancellable)
+		return
+	}
+
+	o.out.State = StateCancelled
+	o.out.CancelURL = ""
+	o.touch()
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+// advance moves an open order one step through its lifecycle.
+func (s *Server) advance(o *order) {
+	switch o.out.State {
+	case StateUnconfirmed:
+		o.out.State = StateConfirmed
+		o.touch()
+	case StateConfirmed:
+		q := s.quotes[o.symbol]
+		if q == nil {
+			return
+		}
+		px := q.LastTradePrice
+		if o.typ == "market" ||
+			(o.side == "buy" && px.LessThanOrEqual(o.price.Decimal)) ||
+			(o.side == "sell" && px.GreaterThanOrEqual(o.price.Decimal)) {
+			s.fill(o, px)
+		}
+	}
+}
+
+// fill fills the whole order at px and updates the position and account.
+func (s *Server) fill(o *order, px robinhood.Money) {
+	now := time.Now()
+	o.out.Executions = append(o.out.Executions, map[string]interface{}{
+		"id":        uuid.New().String(),
+		"price":     px,
+		"quantity":  o.qty,
+		"timestamp": robinhood.Timestamp{Time: now},
+	})
+	o.out.State = StateFilled
+	o.out.CancelURL = ""
+	o.out.AveragePrice = px
+	o.out.CumulativeQuantity = o.qty
+	o.touch()
+
+	p := s.position(o.accountURL, o.instrURL)
+	a := s.accountByURL(o.accountURL)
+	cost := px.Times(o.qty)
+
+	switch o.side {
+	case "buy":
+		total := p.AverageBuyPrice.Times(p.Quantity).Add(cost.Decimal)
+		p.Quantity = robinhood.NewQuantity(p.Quantity.Add(o.qty.Decimal))
+		p.AverageBuyPrice = robinhood.NewMoney(total.Div(p.Quantity.Decimal))
+		a.Cash = robinhood.NewMoney(a.Cash.Sub(cost.Decimal))
+		a.BuyingPower = robinhood.NewMoney(a.BuyingPower.Sub(cost.Decimal))
+	case "sell":
+		p.Quantity = robinhood.NewQuantity(p.Quantity.Sub(o.qty.Decimal))
+		if p.Quantity.IsZero() {
+			p.AverageBuyPrice = robinhood.Money{}
+		}
+		a.Cash = robinhood.NewMoney(a.Cash.Add(cost.Decimal))
+		a.BuyingPower = robinhood.NewMoney(a.BuyingPower.Add(cost.Decimal))
+	}
+	p.UpdatedAt = now
+}
+
+func (o *order) touch() {
+	o.out.UpdatedAt = time.Now()
+	o.out.LastTransactionAt = robinhood.Timestamp{Time: o.out.UpdatedAt}
+}
+
+func (s *Server) instrumentByURL(u string) *robinhood.Instrument {
+	for _, i := range s.instruments {
+		if i.URL == u {
+			return i
+		}
+	}
+	return nil
+}
+
+func cancellable(state string) bool {
+	return state == StateUnconfirmed || state == StateConfirmed
+}
@@ -0,0 +1,936 @@
+// Package robinhoodtest provides an in-process fake of the Robinhood API for
+// use in tests. A Server keeps all of its state in memory, so orders placed
+// through a robinhood.Client can be updated, filled and cancelled without ever
+// touching a real brokerage account.
+package robinhoodtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"astuart.co/go-robinhood/v2"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+)
+
+// Path prefixes under which the fake hosts are served.
+const (
+	apiPrefix     = "/api/"
+	nummusPrefix  = "/nummus/"
+	phoenixPrefix = "/phoenix/"
+)
+
+// DefaultPageSize is the number of results returned per page by list
+// endpoints unless Server.PageSize is changed.
+const DefaultPageSize = 100
+
+// A Server is a fake Robinhood API server. The zero value is not usable; use
+// NewServer.
+type Server struct {
+	*httptest.Server
+
+	// PageSize is the number of results returned per page by list endpoints.
+	PageSize int
+
+	// MaxBatch, if positive, is the most symbols or instruments accepted by a
+	// single multi-item request such as quotes or fundamentals. Larger
+	// requests fail with 400 Bad Request, as the real API does.
+	MaxBatch int
+
+	mu sync.Mutex
+
+	marketClosed bool
+	throttled    int
+	retryAfter   int
+	faults       []*fault
+	log          []string
+
+	username, password, mfaCode string
+	locked                      bool
+	loginThrottled, loginAfter  int
+	tokens                      map[string]bool
+	refreshTokens               map[string]bool
+	grants                      []string
+	token                       string
+
+	challengeType, challengeCode string
+	devices                      map[string]bool // verified device tokens
+	challenges                   map[string]*challenge
+
+	accounts       []*robinhood.Account
+	cryptoAccounts []*robinhood.CryptoAccount
+
+	instruments   map[string]*robinhood.Instrument  // by ID
+	instrumentIDs []string                          // in creation order
+	symbols       map[string]string                 // symbol -> instrument ID
+	quotes        map[string]*robinhood.Quote       // by symbol
+	fundamentals  map[string]*robinhood.Fundamental // by symbol
+	positions     map[string]*robinhood.Position    // by account and instrument URL
+	positionKeys  []string                          // in creation order
+
+	orders     map[string]*order
+	orderIDs   []string
+	cryptoOrds map[string]*cryptoOrder
+
+	pairs    map[string]*pair                     // by currency pair ID
+	holdings map[string]*robinhood.CryptoPosition // by currency code
+
+	chains      map[string]*robinhood.OptionChain // by chain ID
+	options     []*robinhood.OptionInstrument
+	marketData  map[string]*robinhood.MarketData // by option instrument URL
+	unifiedAcct *robinhood.UnifiedAccount
+}
+
+// NewServer starts and returns a new fake server with a single margin account
+// holding $10,000 of buying power and a single crypto account. The caller
+// should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		PageSize:      DefaultPageSize,
+		username:      "user",
+		password:      "password",
+		tokens:        map[string]bool{},
+		refreshTokens: map[string]bool{},
+		devices:       map[string]bool{},
+		challenges:    map[string]*challenge{},
+		instruments:   map[string]*robinhood.Instrument{},
+		symbols:       map[string]string{},
+		quotes:        map[string]*robinhood.Quote{},
+		fundamentals:  map[string]*robinhood.Fundamental{},
+		positions:     map[string]*robinhood.Position{},
+		orders:        map[string]*order{},
+		cryptoOrds:    map[string]*cryptoOrder{},
+		pairs:         map[string]*pair{},
+		holdings:      map[string]*robinhood.CryptoPosition{},
+		chains:        map[string]*robinhood.OptionChain{},
+		marketData:    map[string]*robinhood.MarketData{},
+	}
+	s.token = s.issueToken()
+
+	mux := http.NewServeMux()
+	mux.Handle(apiPrefix, s.authed(http.HandlerFunc(s.serveAPI)))
+	mux.Handle(nummusPrefix, s.authed(http.HandlerFunc(s.serveNummus)))
+	mux.Handle(phoenixPrefix, s.authed(http.HandlerFunc(s.servePhoenix)))
+	s.Server = httptest.NewServer(mux)
+
+	s.AddAccount(robinhood.Account{
+		AccountNumber: "5RY00000",
+		BuyingPower:   robinhood.MustMoney("10000"),
+		Cash:          robinhood.MustMoney("10000"),
+		Type:          "margin",
+	})
+	s.cryptoAccounts = append(s.cryptoAccounts, &robinhood.CryptoAccount{
+		ID:     uuid.New().String(),
+		Status: "active",
+		UserID: uuid.New().String(),
+	})
+
+	return s
+}
+
+// Endpoints returns the endpoint set that routes a robinhood.Client to this
+// server.
+func (s *Server) Endpoints() robinhood.Endpoints {
+	return robinhood.NewEndpoints(
+		s.URL+apiPrefix,
+		s.URL+nummusPrefix,
+		s.URL+phoenixPrefix,
+	)
+}
+
+// TokenSource returns a token source whose token is accepted by the server.
+func (s *Server) TokenSource() oauth2.TokenSource {
+	return oauth2.StaticTokenSource(&oauth2.Token{
+		AccessToken: s.token,
+		TokenType:   "Bearer",
+	})
+}
+
+// Dial returns a robinhood.Client that is authenticated against and talks to
+// this server.
+func (s *Server) Dial(ctx context.Context, opts ...robinhood.DialOption) (*robinhood.Client, error) {
+	opts = append([]robinhood.DialOption{robinhood.WithEndpoints(s.Endpoints())}, opts...)
+	return robinhood.Dial(ctx, s.TokenSource(), opts...)
+}
+
+// SetCredentials changes the username and password accepted by the token
+// endpoint. If mfa is not empty, logins must also supply it as the mfa_code.
+func (s *Server) SetCredentials(username, password, mfa string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.username, s.password, s.mfaCode = username, password, mfa
+}
+
+// LockAccount makes logins fail as they do for a locked account, until it is
+// called again with false.
+func (s *Server) LockAccount(locked bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.locked = locked
+}
+
+// ThrottleLogins makes the next n logins fail with 429 Too Many Requests and
+// a Retry-After of retryAfterSeconds.
+func (s *Server) ThrottleLogins(n, retryAfterSeconds int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loginThrottled, s.loginAfter = n, retryAfterSeconds
+}
+
+// RequireDeviceVerification makes logins from device tokens that have not yet
+// been verified answer a challenge of the given type ("sms" or "email") with
+// code before a token is issued.
+func (s *Server) RequireDeviceVerification(typ, code string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.challengeType, s.challengeCode = typ, code
+}
+
+// DeviceVerified reports whether the device token has passed a challenge.
+func (s *Server) DeviceVerified(deviceToken string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.devices[deviceToken]
+}
+
+// RevokeTokens invalidates every access and refresh token the server has
+// issued, as happens when a session is ended server-side.
+func (s *Server) RevokeTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = map[string]bool{}
+	s.refreshTokens = map[string]bool{}
+}
+
+// ExpireTokens invalidates every access token the server has issued, as
+// happens when they expire, but leaves refresh tokens usable.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = map[string]bool{}
+}
+
+// TokenActive reports whether tok is an access or refresh token that has been
+// issued and not revoked or expired.
+func (s *Server) TokenActive(tok string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens[tok] || s.refreshTokens[tok]
+}
+
+// Grants returns the grant type ("password" or "refresh_token") of every
+// token the token endpoint has issued, in order.
+func (s *Server) Grants() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.grants...)
+}
+
+// AddAccount adds a brokerage account to the server and returns it with its
+// URL and metadata filled in.
+func (s *Server) AddAccount(a robinhood.Account) robinhood.Account {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if a.AccountNumber == "" {
+		a.AccountNumber = fmt.Sprintf("5RY%05d", len(s.accounts))
+	}
+	if a.Type == "" {
+		a.Type = "cash"
+	}
+	a.URL = s.URL + apiPrefix + "accounts/" + a.AccountNumber + "/"
+	a.Positions = s.URL + apiPrefix + "positions/"
+	a.CreatedAt = time.Now()
+	a.UpdatedAt = a.CreatedAt
+	s.accounts = append(s.accounts, &a)
+	return a
+}
+
+// ClearAccounts removes every brokerage and crypto account, as for a login
+// that has not opened any.
+func (s *Server) ClearAccounts() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts = nil
+	s.cryptoAccounts = nil
+}
+
+// Account returns the current state of the account with the given number.
+func (s *Server) Account(number string) (robinhood.Account, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a := s.accountByNumber(number)
+	if a == nil {
+		return robinhood.Account{}, false
+	}
+	return *a, true
+}
+
+// AddStock adds a tradable equity instrument with the given last trade price
+// and returns it.
+func (s *Server) AddStock(symbol string, price robinhood.Money) robinhood.Instrument {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := uuid.New().String()
+	i := &robinhood.Instrument{
+		ID:          id,
+		Symbol:      symbol,
+		Name:        symbol,
+		State:       "active",
+		Tradeable:   true,
+		Tradability: "tradable",
+		Type:        "stock",
+		URL:         s.URL + apiPrefix + "instruments/" + id + "/",
+		Quote:       s.URL + apiPrefix + "quotes/" + symbol + "/",
+	}
+	s.instruments[id] = i
+	s.instrumentIDs = append(s.instrumentIDs, id)
+	s.symbols[symbol] = id
+	s.quotes[symbol] = &robinhood.Quote{
+		Symbol:                      symbol,
+		AskPrice:                    price,
+		BidPrice:                    price,
+		LastTradePrice:              price,
+		LastExtendedHoursTradePrice: price,
+		PreviousClose:               price,
+		AdjustedPreviousClose:       price,
+	}
+	s.fundamentals[symbol] = &robinhood.Fundamental{
+		Open:        price,
+		High:        price,
+		Low:         price,
+		Description: symbol,
+		Instrument:  i.URL,
+	}
+	return *i
+}
+
+// SetQuote replaces the quote for q.Symbol, which must already have been added
+// with AddStock.
+func (s *Server) SetQuote(q robinhood.Quote) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quotes[q.Symbol] = &q
+}
+
+// SetMarketOpen controls whether equity orders are accepted. When the market
+// is closed, new orders are refused with DetailMarketClosed.
+func (s *Server) SetMarketOpen(open bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.marketClosed = !open
+}
+
+// Throttle makes the server respond to the next n authenticated requests with
+// 429 Too Many Requests, asking clients to retry after the given number of
+// seconds.
+func (s *Server) Throttle(n, retryAfterSeconds int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.throttled, s.retryAfter = n, retryAfterSeconds
+}
+
+// FailRequests makes the server respond to the next n authenticated requests
+// whose URI (path and query) contains match with the given error status. An
+// empty match matches every request.
+func (s *Server) FailRequests(n, status int, match string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &fault{n: n, status: status, match: match})
+}
+
+// RequestLog returns the method and URI of every authenticated request the
+// server has received, in order.
+func (s *Server) RequestLog() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.log...)
+}
+
+type fault struct {
+	n, status int
+	match     string
+}
+
+// fault returns the status of the first injected fault matching r, if any.
+func (s *Server) fault(r *http.Request) (int, bool) {
+	for _, f := range s.faults {
+		if f.n > 0 && strings.Contains(r.URL.RequestURI(), f.match) {
+			f.n--
+			return f.status, true
+		}
+	}
+	return 0, false
+}
+
+// SetUnifiedAccount sets the response of the phoenix unified account
+// endpoint.
+func (s *Server) SetUnifiedAccount(u robinhood.UnifiedAccount) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unifiedAcct = &u
+}
+
+func (s *Server) issueToken() string {
+	t := uuid.New().String()
+	s.tokens[t] = true
+	return t
+}
+
+// authed rejects requests without a valid bearer token, except for the token,
+// revoke and challenge endpoints.
+func (s *Server) authed(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, apiPrefix+"oauth2/") || strings.HasPrefix(r.URL.Path, apiPrefix+"challenge/") {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		tok := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		s.mu.Lock()
+		ok := s.tokens[tok]
+		s.mu.Unlock()
+
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "Invalid token.")
+			return
+		}
+
+		s.mu.Lock()
+		s.log = append(s.log, r.Method+" "+r.URL.RequestURI())
+		throttled, after := s.throttled > 0, s.retryAfter
+		if throttled {
+			s.throttled--
+		}
+		status, failed := s.fault(r)
+		s.mu.Unlock()
+
+		if failed {
+			writeError(w, status, http.StatusText(status))
+			return
+		}
+
+		if throttled {
+			w.Header().Set("Retry-After", strconv.Itoa(after))
+			writeError(w, http.StatusTooManyRequests,
+				fmt.Sprintf("Request was throttled. Expected available in %d seconds.", after))
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	parts := split(r.URL.Path, apiPrefix)
+
+	switch {
+	case match(parts, "oauth2", "token"):
+		s.login(w, r)
+	case match(parts, "oauth2", "revoke_token"):
+		s.revoke(w, r)
+	case match(parts, "challenge", "*", "respond"):
+		s.respondChallenge(w, r, parts[1])
+	case match(parts, "accounts"):
+		s.listAccounts(w, r)
+	case match(parts, "accounts", "*"):
+		s.getAccount(w, r, parts[1])
+	case match(parts, "positions"):
+		s.listPositions(w, r)
+	case match(parts, "quotes"):
+		s.listQuotes(w, r)
+	case match(parts, "quotes", "*"):
+		s.getQuote(w, r, parts[1])
+	case match(parts, "fundamentals"):
+		s.listFundamentals(w, r)
+	case match(parts, "instruments"):
+		s.listInstruments(w, r)
+	case match(parts, "instruments", "*"):
+		s.getInstrument(w, r, parts[1])
+	case match(parts, "orders"):
+		if r.Method == http.MethodPost {
+			s.createOrder(w, r)
+			return
+		}
+		s.listOrders(w, r)
+	case match(parts, "orders", "*"):
+		s.getOrder(w, r, parts[1])
+	case match(parts, "orders", "*", "cancel"):
+		s.cancelOrder(w, r, parts[1])
+	case match(parts, "options", "chains"):
+		s.listChains(w, r)
+	case match(parts, "options", "instruments"):
+		s.listOptionInstruments(w, r)
+	case match(parts, "marketdata", "options"):
+		s.listMarketData(w, r)
+	default:
+		writeError(w, http.StatusNotFound, "Not found.")
+	}
+}
+
+func (s *Server) serveNummus(w http.ResponseWriter, r *http.Request) {
+	parts := split(r.URL.Path, nummusPrefix)
+
+	switch {
+	case match(parts, "accounts"):
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.writePage(w, r, s.cryptoAccounts)
+	case match(parts, "currency_pairs"):
+		s.listPairs(w, r)
+	case match(parts, "holdings"):
+		s.listHoldings(w, r)
+	case match(parts, "orders"):
+		if r.Method == http.MethodPost {
+			s.createCryptoOrder(w, r)
+			return
+		}
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed.")
+	case match(parts, "orders", "*"):
+		s.getCryptoOrder(w, r, parts[1])
+	case match(parts, "orders", "*", "cancel"):
+		s.cancelCryptoOrder(w, r, parts[1])
+	default:
+		writeError(w, http.StatusNotFound, "Not found.")
+	}
+}
+
+func (s *Server) servePhoenix(w http.ResponseWriter, r *http.Request) {
+	parts := split(r.URL.Path, phoenixPrefix)
+	if !match(parts, "accounts", "unified") {
+		writeError(w, http.StatusNotFound, "Not found.")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.unifiedAcct == nil {
+		writeJSON(w, http.StatusOK, robinhood.UnifiedAccount{})
+		return
+	}
+	writeJSON(w, http.StatusOK, s.unifiedAcct)
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed.")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.loginThrottled > 0 {
+		s.loginThrottled--
+		w.Header().Set("Retry-After", strconv.Itoa(s.loginAfter))
+		writeError(w, http.StatusTooManyRequests,
+			fmt.Sprintf("Request was throttled. Expected available in %d seconds.", s.loginAfter))
+		return
+	}
+
+	if r.Form.Get("grant_type") == "refresh_token" {
+		rt := r.PostForm.Get("refresh_token")
+		if !s.refreshTokens[rt] {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		delete(s.refreshTokens, rt)
+		s.writeToken(w, "refresh_token")
+		return
+	}
+
+	if r.PostForm.Get("username") != s.username || r.PostForm.Get("password") != s.password {
+		writeError(w, http.StatusBadRequest, "Unable to log in with provided credentials.")
+		return
+	}
+	if s.locked {
+		writeError(w, http.StatusForbidden, "Your account has been locked. Please contact support.")
+		return
+	}
+
+	if dt := r.PostForm.Get("device_token"); s.challengeCode != "" && !s.devices[dt] {
+		ch, ok := s.challenges[r.Header.Get(robinhood.ChallengeResponseHeader)]
+		if !ok || ch.Status != "validated" || ch.device != dt {
+			ch = s.issueChallenge(dt)
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"detail":    "Request blocked, challenge issued.",
+				"challenge": ch,
+			})
+			return
+		}
+		s.devices[dt] = true
+	}
+
+	if s.mfaCode != "" {
+		code := r.PostForm.Get("mfa_code")
+		if code == "" {
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"mfa_required": true,
+				"mfa_type":     "sms",
+			})
+			return
+		}
+		if code != s.mfaCode {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"mfa_code": []string{"Please enter a valid code."},
+			})
+			return
+		}
+	}
+
+	s.writeToken(w, "password")
+}
+
+// revoke invalidates an access or refresh token. Like the real endpoint, it
+// succeeds for unknown tokens.
+func (s *Server) revoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed.")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	tok := r.PostForm.Get("token")
+	if tok == "" {
+		writeError(w, http.StatusBadRequest, "token is required.")
+		return
+	}
+
+	s.mu.Lock()
+	delete(s.tokens, tok)
+	delete(s.refreshTokens, tok)
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+// writeToken issues a new access and refresh token. s.mu must be held.
+func (s *Server) writeToken(w http.ResponseWriter, grant string) {
+	rt := uuid.New().String()
+	s.refreshTokens[rt] = true
+	s.grants = append(s.grants, grant)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token":  s.issueToken(),
+		"refresh_token": rt,
+		"expires_in":    86400,
+		"token_type":    "Bearer",
+		"scope":         "internal",
+	})
+}
+
+type challenge struct {
+	robinhood.MFAChallenge
+	device string
+}
+
+func (s *Server) issueChallenge(deviceToken string) *challenge {
+	ch := &challenge{
+		MFAChallenge: robinhood.MFAChallenge{
+			ID:                uuid.New().String(),
+			Type:              s.challengeType,
+			Status:            "issued",
+			RemainingAttempts: 3,
+			ExpiresAt:         robinhood.Timestamp{Time: time.Now().Add(5 * time.Minute)},
+		},
+		device: deviceToken,
+	}
+	s.challenges[ch.ID] = ch
+	return ch
+}
+
+func (s *Server) respondChallenge(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed.")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch, ok := s.challenges[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not found.")
+		return
+	}
+	if ch.Status != "issued" {
+		writeError(w, http.StatusBadRequest, "Challenge is no longer active.")
+		return
+	}
+
+	if r.PostForm.Get("response") != s.challengeCode {
+		ch.RemainingAttempts--
+		if ch.RemainingAttempts <= 0 {
+			ch.Status = "failed"
+		}
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"detail":    "Challenge response is invalid.",
+			"challenge": ch,
+		})
+		return
+	}
+
+	ch.Status = "validated"
+	writeJSON(w, http.StatusOK, ch)
+}
+
+func (s *Server) listAccounts(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writePage(w, r, s.accounts)
+}
+
+func (s *Server) getAccount(w http.ResponseWriter, r *http.Request, number string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a := s.accountByNumber(number)
+	if a == nil {
+		writeError(w, http.StatusNotFound, "Not found.")
+		return
+	}
+	writeJSON(w, http.StatusOK, a)
+}
+
+func (s *Server) accountByNumber(number string) *robinhood.Account {
+	for _, a := range s.accounts {
+		if a.AccountNumber == number {
+			return a
+		}
+	}
+	return nil
+}
+
+func (s *Server) accountByURL(u string) *robinhood.Account {
+	for _, a := range s.accounts {
+		if a.URL == u {
+			return a
+		}
+	}
+	return nil
+}
+
+func (s *Server) listQuotes(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	syms, ok := s.batchParam(w, r, "symbols")
+	if !ok {
+		return
+	}
+
+	// Like the real API, unknown symbols are returned as null in order.
+	qs := make([]*robinhood.Quote, len(syms))
+	for i, sym := range syms {
+		qs[i] = s.quotes[sym]
+	}
+	writeJSON(w, http.StatusOK, results(qs))
+}
+
+func (s *Server) listFundamentals(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	syms, ok := s.batchParam(w, r, "symbols")
+	if !ok {
+		return
+	}
+
+	fs := make([]*robinhood.Fundamental, len(syms))
+	for i, sym := range syms {
+		fs[i] = s.fundamentals[sym]
+	}
+	writeJSON(w, http.StatusOK, results(fs))
+}
+
+// batchParam returns the comma separated list in query parameter key,
+// writing an error if it is missing or longer than MaxBatch.
+func (s *Server) batchParam(w http.ResponseWriter, r *http.Request, key string) ([]string, bool) {
+	vs := splitList(r.URL.Query().Get(key))
+	if len(vs) == 0 {
+		writeError(w, http.StatusBadRequest, key+" is required.")
+		return nil, false
+	}
+	if s.MaxBatch > 0 && len(vs) > s.MaxBatch {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Ensure %s has no more than %d elements.", key, s.MaxBatch))
+		return nil, false
+	}
+	return vs, true
+}
+
+func (s *Server) getQuote(w http.ResponseWriter, r *http.Request, sym string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q, ok := s.quotes[sym]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not found.")
+		return
+	}
+	writeJSON(w, http.StatusOK, q)
+}
+
+func (s *Server) listInstruments(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	is := []*robinhood.Instrument{}
+	if sym := r.URL.Query().Get("symbol"); sym != "" {
+		if id, ok := s.symbols[sym]; ok {
+			is = append(is, s.instruments[id])
+		}
+	} else {
+		for _, id := range s.instrumentIDs {
+			is = append(is, s.instruments[id])
+		}
+	}
+	s.writePage(w, r, is)
+}
+
+func (s *Server) getInstrument(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.instruments[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not found.")
+		return
+	}
+	writeJSON(w, http.StatusOK, i)
+}
+
+func (s *Server) listPositions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := r.URL.Query()
+	nonzero := q.Get("nonzero") == "true"
+	acctURL := ""
+	if n := q.Get("account_number"); n != "" {
+		a := s.accountByNumber(n)
+		if a == nil {
+			writeError(w, http.StatusNotFound, "Not found.")
+			return
+		}
+		acctURL = a.URL
+	}
+
+	ps := []*robinhood.Position{}
+	for _, k := range s.positionKeys {
+		p := s.positions[k]
+		if nonzero && p.Quantity.IsZero() {
+			continue
+		}
+		if acctURL != "" && p.Account != acctURL {
+			continue
+		}
+		ps = append(ps, p)
+	}
+	s.writePage(w, r, ps)
+}
+
+// writePage writes a single page of a list endpoint response, selected by the
+// "cursor" query parameter, with a link to the next page if there is one.
+func (s *Server) writePage(w http.ResponseWriter, r *http.Request, items interface{}) {
+	all := toSlice(items)
+
+	start, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+	if start < 0 || start > len(all) {
+		start = len(all)
+	}
+	end := len(all)
+	if s.PageSize > 0 && start+s.PageSize < end {
+		end = start + s.PageSize
+	}
+
+	var page struct {
+		Next     *string       `json:"next"`
+		Previous *string       `json:"previous"`
+		Results  []interface{} `json:"results"`
+	}
+	page.Results = all[start:end]
+	if end < len(all) {
+		u := *r.URL
+		u.Scheme, u.Host = "http", r.Host
+		q := u.Query()
+		q.Set("cursor", strconv.Itoa(end))
+		u.RawQuery = q.Encode()
+		next := u.String()
+		page.Next = &next
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// results wraps the response of a batch endpoint, which unlike a list
+// endpoint is not paginated.
+func results(items interface{}) interface{} {
+	return map[string]interface{}{
+		"results": items,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, detail string) {
+	writeJSON(w, status, map[string]string{"detail": detail})
+}
+
+// split returns the non-empty path segments of p after prefix.
+func split(p, prefix string) []string {
+	return strings.FieldsFunc(strings.TrimPrefix(p, prefix), func(r rune) bool {
+		return r == '/'
+	})
+}
+
+// match reports whether path segments match pattern, where "*" matches any
+// single segment.
+func match(parts []string, pattern ...string) bool {
+	if len(parts) != len(pattern) {
+		return false
+	}
+	for i := range parts {
+		if pattern[i] != "*" && pattern[i] != parts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// toSlice converts any slice to a []interface{}.
+func toSlice(items interface{}) []interface{} {
+	v := reflect.ValueOf(items)
+	out := make([]interface{}, v.Len())
+	for i := range out {
+		out[i] = v.Index(i).Interface()
+	}
+	return out
+}
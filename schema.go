@@ -0,0 +1,389 @@
+package robinhood
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrSchemaMismatch is matched by errors.Is for the *SchemaReport returned
+// in SchemaStrict mode when a response does not match the type it was
+// decoded into.
+var ErrSchemaMismatch = errors.New("response does not match schema")
+
+// A SchemaMode controls whether the Client checks API responses against the
+// types they are decoded into.
+type SchemaMode int
+
+// SchemaModes.
+const (
+	// SchemaOff does no checking. It is the default.
+	SchemaOff SchemaMode = iota
+	// SchemaLenient reports mismatches but decodes what it can and never
+	// fails a request because of them, even if a field has the wrong type.
+	SchemaLenient
+	// SchemaStrict reports mismatches and fails the request with the
+	// response's *SchemaReport.
+	SchemaStrict
+)
+
+// A SchemaIssueKind is the kind of a SchemaIssue.
+type SchemaIssueKind int
+
+// SchemaIssueKinds.
+const (
+	// UnknownField is a field in the response that the type does not have.
+	UnknownField SchemaIssueKind = iota + 1
+	// TypeMismatch is a field whose value cannot be decoded into its type.
+	TypeMismatch
+	// MissingField is a field of the type, not marked omitempty, that the
+	// response does not have.
+	MissingField
+)
+
+func (k SchemaIssueKind) String() string {
+	switch k {
+	case UnknownField:
+		return "unknown field"
+	case TypeMismatch:
+		return "type mismatch"
+	case MissingField:
+		return "missing field"
+	}
+	return fmt.Sprintf("SchemaIssueKind(%d)", int(k))
+}
+
+// A SchemaIssue is one difference between a response and its Go type.
+type SchemaIssue struct {
+	Kind SchemaIssueKind
+	// Path locates the field in the response, e.g. "results[].quantity".
+	Path string
+	// Detail describes the issue, e.g. the JSON value and the Go type it
+	// could not be decoded into.
+	Detail string
+}
+
+func (i SchemaIssue) String() string {
+	if i.Detail == "" {
+		return fmt.Sprintf("%s %s", i.Kind, i.Path)
+	}
+	return fmt.Sprintf("%s %s: %s", i.Kind, i.Path, i.Detail)
+}
+
+// A SchemaReport lists the schema issues found for an endpoint, as named by
+// Endpoints.Name. Each issue is listed once, however often it was seen.
+type SchemaReport struct {
+	Endpoint string
+	Issues   []SchemaIssue
+}
+
+func (r *SchemaReport) Error() string {
+	is := make([]string, len(r.Issues))
+	for i, iss := range r.Issues {
+		is[i] = iss.String()
+	}
+	return fmt.Sprintf("%s: %s: %s", ErrSchemaMismatch, r.Endpoint, strings.Join(is, "; "))
+}
+
+// Is makes a *SchemaReport match ErrSchemaMismatch.
+func (r *SchemaReport) Is(target error) bool {
+	return target == ErrSchemaMismatch
+}
+
+// WithSchemaCheck makes the Client check every successful response against
+// the type it is decoded into. If fn is non-nil, it is called with the issues
+// found in each response that has any; it may be called concurrently. The
+// issues are also collected per endpoint and available from SchemaReports.
+func WithSchemaCheck(mode SchemaMode, fn func(*SchemaReport)) DialOption {
+	return func(c *Client) {
+		c.schema = &schemaChecker{mode: mode, fn: fn, reports: map[string]*SchemaReport{}}
+	}
+}
+
+// SchemaReports returns the schema issues seen so far, one report per
+// endpoint, sorted by endpoint. It returns nil unless WithSchemaCheck was
+// used.
+func (c *Client) SchemaReports() []SchemaReport {
+	if c.schema == nil {
+		return nil
+	}
+	return c.schema.snapshot()
+}
+
+type schemaChecker struct {
+	mode SchemaMode
+	fn   func(*SchemaReport)
+
+	mu      sync.Mutex
+	reports map[string]*SchemaReport
+}
+
+// decode unmarshals bs, the body of a response from url, into dest, checking
+// it against dest's type if the client has a schema mode.
+func (c *Client) decode(url string, bs []byte, dest interface{}) error {
+	err := json.Unmarshal(bs, dest)
+	if c.schema == nil || c.schema.mode == SchemaOff {
+		return err
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return err
+	}
+
+	r := &SchemaReport{Endpoint: c.ep().Name(url)}
+	w := schemaWalker{seen: map[SchemaIssue]bool{}}
+	w.check("", bs, reflect.TypeOf(dest))
+	r.Issues = w.issues
+	if len(r.Issues) == 0 {
+		return err
+	}
+
+	c.schema.record(r)
+	if c.schema.fn != nil {
+		c.schema.fn(r)
+	}
+	if c.schema.mode == SchemaStrict {
+		return r
+	}
+	return nil
+}
+
+func (s *schemaChecker) record(r *SchemaReport) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, ok := s.reports[r.Endpoint]
+	if !ok {
+		all = &SchemaReport{Endpoint: r.Endpoint}
+		s.reports[r.Endpoint] = all
+	}
+	for _, iss := range r.Issues {
+		if !hasIssue(all.Issues, iss) {
+			all.Issues = append(all.Issues, iss)
+		}
+	}
+}
+
+func (s *schemaChecker) snapshot() []SchemaReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]SchemaReport, 0, len(s.reports))
+	for _, r := range s.reports {
+		out = append(out, SchemaReport{
+			Endpoint: r.Endpoint,
+			Issues:   append([]SchemaIssue(nil), r.Issues...),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Endpoint < out[j].Endpoint })
+	return out
+}
+
+// hasIssue reports whether is already has an issue of the same kind at the
+// same path as iss.
+func hasIssue(is []SchemaIssue, iss SchemaIssue) bool {
+	for _, i := range is {
+		if i.Kind == iss.Kind && i.Path == iss.Path {
+			return true
+		}
+	}
+	return false
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// schemaWalker compares JSON values with the Go types encoding/json would
+// decode them into.
+type schemaWalker struct {
+	issues []SchemaIssue
+	seen   map[SchemaIssue]bool
+}
+
+func (w *schemaWalker) add(kind SchemaIssueKind, path, detail string) {
+	iss := SchemaIssue{Kind: kind, Path: path}
+	if w.seen[iss] {
+		return
+	}
+	w.seen[iss] = true
+	iss.Detail = detail
+	w.issues = append(w.issues, iss)
+}
+
+func (w *schemaWalker) check(path string, raw json.RawMessage, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	raw = bytes.TrimSpace(raw)
+	if string(raw) == "null" {
+		return
+	}
+
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		w.leaf(path, raw, t)
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(raw, &obj) != nil {
+			w.mismatch(path, raw, t)
+			return
+		}
+		w.object(path, obj, t)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			w.leaf(path, raw, t)
+			return
+		}
+		var arr []json.RawMessage
+		if json.Unmarshal(raw, &arr) != nil {
+			w.mismatch(path, raw, t)
+			return
+		}
+		for _, v := range arr {
+			w.check(path+"[]", v, t.Elem())
+		}
+	case reflect.Map:
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(raw, &obj) != nil {
+			w.mismatch(path, raw, t)
+			return
+		}
+		for _, v := range obj {
+			w.check(join(path, "*"), v, t.Elem())
+		}
+	case reflect.Interface:
+	default:
+		w.leaf(path, raw, t)
+	}
+}
+
+func (w *schemaWalker) object(path string, obj map[string]json.RawMessage, t reflect.Type) {
+	fs := jsonFields(t)
+	found := make(map[*jsonField]bool, len(fs))
+	for k, v := range obj {
+		f := lookupField(fs, k)
+		if f == nil {
+			w.add(UnknownField, join(path, k), "")
+			continue
+		}
+		found[f] = true
+		if f.quoted {
+			w.quoted(join(path, k), v, f.typ)
+			continue
+		}
+		w.check(join(path, k), v, f.typ)
+	}
+	for _, f := range fs {
+		if !found[f] && !f.omitempty {
+			w.add(MissingField, join(path, f.name), "")
+		}
+	}
+}
+
+// quoted checks a field tagged ",string", whose value is JSON inside a JSON
+// string.
+func (w *schemaWalker) quoted(path string, raw json.RawMessage, t reflect.Type) {
+	var s string
+	if json.Unmarshal(raw, &s) != nil {
+		w.mismatch(path, raw, t)
+		return
+	}
+	w.leaf(path, json.RawMessage(s), t)
+}
+
+func (w *schemaWalker) leaf(path string, raw json.RawMessage, t reflect.Type) {
+	if json.Unmarshal(raw, reflect.New(t).Interface()) != nil {
+		w.mismatch(path, raw, t)
+	}
+}
+
+func (w *schemaWalker) mismatch(path string, raw json.RawMessage, t reflect.Type) {
+	v := string(raw)
+	if len(v) > 40 {
+		v = v[:40] + "..."
+	}
+	w.add(TypeMismatch, path, fmt.Sprintf("cannot decode %s into %s", v, t))
+}
+
+func join(path, k string) string {
+	if path == "" {
+		return k
+	}
+	return path + "." + k
+}
+
+type jsonField struct {
+	name      string
+	typ       reflect.Type
+	omitempty bool
+	quoted    bool
+}
+
+// jsonFields returns the fields of struct type t as encoding/json sees them,
+// including those promoted from embedded structs.
+func jsonFields(t reflect.Type) []*jsonField {
+	var fs []*jsonField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		ft := sf.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			fs = append(fs, jsonFields(ft)...)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+		f := &jsonField{name: name, typ: sf.Type}
+		for _, o := range strings.Split(opts, ",") {
+			switch o {
+			case "omitempty":
+				f.omitempty = true
+			case "string":
+				switch ft.Kind() {
+				case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+					reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+					reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+					f.quoted = true
+				}
+			}
+		}
+		fs = append(fs, f)
+	}
+	return fs
+}
+
+// lookupField finds the field for key k, preferring an exact match but, like
+// encoding/json, falling back to a case-insensitive one.
+func lookupField(fs []*jsonField, k string) *jsonField {
+	for _, f := range fs {
+		if f.name == k {
+			return f
+		}
+	}
+	for _, f := range fs {
+		if strings.EqualFold(f.name, k) {
+			return f
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package robinhood_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"astuart.co/go-robinhood/v2"
+	"astuart.co/go-robinhood/v2/robinhoodtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// respondWith is middleware that answers calls to endpoint with body instead
+// of sending them.
+func respondWith(endpoint, body string) robinhood.Middleware {
+	return func(next robinhood.RoundTripFunc) robinhood.RoundTripFunc {
+		return func(call *robinhood.Call) (*http.Response, error) {
+			if call.Endpoint != endpoint {
+				return next(call)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Status:     "200 OK",
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+				Request:    call.Request,
+			}, nil
+		}
+	}
+}
+
+func TestSchemaCheckMatchesFake(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	spy := s.AddStock("SPY", robinhood.MustMoney("100"))
+	s.SetPosition(spy, robinhood.QuantityFromInt(1), robinhood.MustMoney("90"))
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx, robinhood.WithSchemaCheck(robinhood.SchemaStrict, nil))
+	require.NoError(t, err)
+
+	_, err = c.GetQuote(ctx, "SPY")
+	require.NoError(t, err)
+	_, err = c.GetPositions(ctx)
+	require.NoError(t, err)
+	o, err := c.Order(ctx, &spy, robinhood.OrderOpts{Side: robinhood.Buy, Type: robinhood.Market, Quantity: robinhood.QuantityFromInt(1)})
+	require.NoError(t, err)
+	require.NoError(t, o.Update(ctx))
+
+	assert.Empty(t, c.SchemaReports())
+}
+
+func TestSchemaCheckLenient(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	drifted := `{"results":[{"symbol":"SPY","last_trade_price":"101.5","ask_size":"lots","new_field":true}]}`
+
+	var got []*robinhood.SchemaReport
+	ctx := context.Background()
+	c, err := s.Dial(ctx,
+		robinhood.WithMiddleware(respondWith("Quotes", drifted)),
+		robinhood.WithSchemaCheck(robinhood.SchemaLenient, func(r *robinhood.SchemaReport) { got = append(got, r) }),
+	)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		qs, err := c.GetQuote(ctx, "SPY")
+		require.NoError(t, err)
+		require.Len(t, qs, 1)
+		assert.Equal(t, "101.5", qs[0].LastTradePrice.String())
+	}
+	require.Len(t, got, 2)
+	assert.Equal(t, "Quotes", got[0].Endpoint)
+
+	kinds := map[string]robinhood.SchemaIssueKind{}
+	for _, iss := range got[0].Issues {
+		kinds[iss.Path] = iss.Kind
+	}
+	assert.Equal(t, robinhood.UnknownField, kinds["results[].new_field"])
+	assert.Equal(t, robinhood.TypeMismatch, kinds["results[].ask_size"])
+	assert.Equal(t, robinhood.MissingField, kinds["results[].bid_price"])
+
+	rs := c.SchemaReports()
+	require.Len(t, rs, 1)
+	assert.Equal(t, "Quotes", rs[0].Endpoint)
+	assert.Len(t, rs[0].Issues, len(got[0].Issues))
+}
+
+func TestSchemaCheckStrict(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+	s.AddStock("SPY", robinhood.MustMoney("100"))
+
+	drifted := `{"results":[{"symbol":"SPY","last_trade_price":"101.5","new_field":true}]}`
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx,
+		robinhood.WithMiddleware(respondWith("Quotes", drifted)),
+		robinhood.WithSchemaCheck(robinhood.SchemaStrict, nil),
+	)
+	require.NoError(t, err)
+
+	_, err = c.GetQuote(ctx, "SPY")
+	assert.True(t, errors.Is(err, robinhood.ErrSchemaMismatch), "%v", err)
+	var r *robinhood.SchemaReport
+	if assert.True(t, errors.As(err, &r)) {
+		assert.Equal(t, "Quotes", r.Endpoint)
+		assert.Contains(t, err.Error(), "unknown field results[].new_field")
+	}
+}
+
+func TestUnifiedAccountAmounts(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+
+	var u robinhood.UnifiedAccount
+	u.PortfolioEquity.Amount = robinhood.MustMoney("1234.56")
+	u.PortfolioEquity.CurrencyCode = "USD"
+	s.SetUnifiedAccount(u)
+
+	ctx := context.Background()
+	c, err := s.Dial(ctx)
+	require.NoError(t, err)
+
+	got, err := c.GetUnifiedAccount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "1234.56", got.PortfolioEquity.Amount.String())
+}
@@ -0,0 +1,390 @@
+package robinhood
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// A TokenStore persists the token cached by a CredsCacher. Load returns nil
+// and no error if there is no token stored.
+type TokenStore interface {
+	Load() (*oauth2.Token, error)
+	Save(*oauth2.Token) error
+	Delete() error
+}
+
+// A LockingStore is a TokenStore shared between processes. CredsCacher holds
+// its lock while renewing the token, so that only one process logs in and the
+// others use the token it saved.
+type LockingStore interface {
+	TokenStore
+	// Lock blocks until the store is locked and returns a func to unlock it.
+	Lock() (unlock func() error, err error)
+}
+
+// configDir returns ~/.config, or "" if the home directory cannot be found.
+func configDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		u, err := user.Current()
+		if err != nil {
+			return ""
+		}
+		home = u.HomeDir
+	}
+	return filepath.Join(home, ".config")
+}
+
+// DefaultTokenPath returns the file tokens are cached in by default,
+// ~/.config/robinhood.token. It is resolved each time it is called.
+func DefaultTokenPath() string {
+	dir := configDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "robinhood.token")
+}
+
+// A FileStore stores a token in a file readable only by its owner.
+type FileStore struct {
+	// Path is the file the token is stored in. If empty, DefaultTokenPath
+	// is used.
+	Path string
+	// Encryption, if set, encrypts the stored token. A plaintext token left
+	// by an earlier version is encrypted the next time it is loaded.
+	Encryption *TokenEncryption
+}
+
+func (f *FileStore) path() (string, error) {
+	if f.Path != "" {
+		return f.Path, nil
+	}
+	if p := DefaultTokenPath(); p != "" {
+		return p, nil
+	}
+	return "", fmt.Errorf("no token path given and no home directory found")
+}
+
+// Load implements TokenStore. A token that cannot be parsed is treated as
+// missing. A plaintext token is encrypted if the store should be, and a file
+// readable by others is made private.
+func (f *FileStore) Load() (*oauth2.Token, error) {
+	p, err := f.path()
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if fi.Mode().Perm()&0077 != 0 {
+		if err := os.Chmod(p, 0600); err != nil {
+			return nil, err
+		}
+	}
+
+	bs, err := ioutil.ReadFile(p)
+	if err != nil || len(bs) == 0 {
+		return nil, err
+	}
+
+	sealed := isSealed(bs)
+	if sealed {
+		if f.Encryption == nil {
+			return nil, fmt.Errorf("%w: %s is encrypted but no encryption is configured", ErrTokenDecrypt, p)
+		}
+		if bs, err = f.Encryption.open(bs); err != nil {
+			return nil, err
+		}
+	}
+
+	var o oauth2.Token
+	if json.Unmarshal(bs, &o) != nil {
+		return nil, nil
+	}
+	if !sealed && f.Encryption != nil {
+		if err := f.Save(&o); err != nil {
+			return nil, err
+		}
+	}
+	return &o, nil
+}
+
+// Save implements TokenStore, creating the file's directory if needed.
+func (f *FileStore) Save(tok *oauth2.Token) error {
+	p, err := f.path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0750); err != nil {
+		return fmt.Errorf("error creating path for token: %s", err)
+	}
+
+	bs, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+	if f.Encryption != nil {
+		if bs, err = f.Encryption.seal(bs); err != nil {
+			return err
+		}
+	}
+
+	return writeFileAtomic(p, append(bs, '\n'), 0600)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// over path, so that other processes never read a partly written file and a
+// crash never leaves one behind.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Lock implements LockingStore with an advisory lock on a file next to the
+// token, named like it with a ".lock" suffix. The lock file is left in place.
+func (f *FileStore) Lock() (func() error, error) {
+	p, err := f.path()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0750); err != nil {
+		return nil, fmt.Errorf("error creating path for token: %s", err)
+	}
+
+	lf, err := os.OpenFile(p+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := flock(lf); err != nil {
+		lf.Close()
+		return nil, fmt.Errorf("could not lock %s: %w", lf.Name(), err)
+	}
+	return func() error {
+		defer lf.Close()
+		return funlock(lf)
+	}, nil
+}
+
+// Delete implements TokenStore. Deleting a missing file is not an error.
+func (f *FileStore) Delete() error {
+	p, err := f.path()
+	if err != nil {
+		return err
+	}
+	err = os.Remove(p)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// A MemoryStore keeps a token in memory, for tests and short-lived
+// processes. The zero value is an empty store.
+type MemoryStore struct {
+	mu  sync.Mutex
+	tok *oauth2.Token
+}
+
+// Load implements TokenStore.
+func (m *MemoryStore) Load() (*oauth2.Token, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.tok == nil {
+		return nil, nil
+	}
+	tok := *m.tok
+	return &tok, nil
+}
+
+// Save implements TokenStore.
+func (m *MemoryStore) Save(tok *oauth2.Token) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := *tok
+	m.tok = &t
+	return nil
+}
+
+// Delete implements TokenStore.
+func (m *MemoryStore) Delete() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tok = nil
+	return nil
+}
+
+// An EnvStore reads a token from an environment variable, such as one set
+// from a mounted secret. The variable holds either the JSON of an
+// oauth2.Token or a bare access token, which is assumed not to expire.
+//
+// Save and Delete only change the variable in the current process.
+type EnvStore struct {
+	// Var is the name of the variable, e.g. "ROBINHOOD_TOKEN".
+	Var string
+}
+
+// Load implements TokenStore.
+func (e *EnvStore) Load() (*oauth2.Token, error) {
+	v := strings.TrimSpace(os.Getenv(e.Var))
+	if v == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(v, "{") {
+		return &oauth2.Token{AccessToken: v, TokenType: "Bearer"}, nil
+	}
+
+	var o oauth2.Token
+	if err := json.Unmarshal([]byte(v), &o); err != nil {
+		return nil, fmt.Errorf("could not parse token in $%s: %w", e.Var, err)
+	}
+	return &o, nil
+}
+
+// Save implements TokenStore.
+func (e *EnvStore) Save(tok *oauth2.Token) error {
+	bs, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+	return os.Setenv(e.Var, string(bs))
+}
+
+// Delete implements TokenStore.
+func (e *EnvStore) Delete() error {
+	return os.Unsetenv(e.Var)
+}
+
+// profileExt is the extension of the token files in a ProfileStore.
+const profileExt = ".token"
+
+// A ProfileStore stores the token of one of several named profiles, each in
+// its own file in a directory, so that several accounts can be used from the
+// same machine.
+type ProfileStore struct {
+	// Dir holds one file per profile. If empty, ~/.config/robinhood is used.
+	Dir string
+	// Name is the profile to use. If empty, "default" is used.
+	Name string
+	// Encryption, if set, encrypts the stored tokens.
+	Encryption *TokenEncryption
+}
+
+func (p *ProfileStore) dir() (string, error) {
+	if p.Dir != "" {
+		return p.Dir, nil
+	}
+	if dir := configDir(); dir != "" {
+		return filepath.Join(dir, "robinhood"), nil
+	}
+	return "", fmt.Errorf("no profile directory given and no home directory found")
+}
+
+func (p *ProfileStore) file() (*FileStore, error) {
+	dir, err := p.dir()
+	if err != nil {
+		return nil, err
+	}
+	name := p.Name
+	if name == "" {
+		name = "default"
+	}
+	if name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("invalid profile name %q", name)
+	}
+	return &FileStore{Path: filepath.Join(dir, name+profileExt), Encryption: p.Encryption}, nil
+}
+
+// Load implements TokenStore.
+func (p *ProfileStore) Load() (*oauth2.Token, error) {
+	f, err := p.file()
+	if err != nil {
+		return nil, err
+	}
+	return f.Load()
+}
+
+// Save implements TokenStore.
+func (p *ProfileStore) Save(tok *oauth2.Token) error {
+	f, err := p.file()
+	if err != nil {
+		return err
+	}
+	return f.Save(tok)
+}
+
+// Delete implements TokenStore.
+func (p *ProfileStore) Delete() error {
+	f, err := p.file()
+	if err != nil {
+		return err
+	}
+	return f.Delete()
+}
+
+// Lock implements LockingStore.
+func (p *ProfileStore) Lock() (func() error, error) {
+	f, err := p.file()
+	if err != nil {
+		return nil, err
+	}
+	return f.Lock()
+}
+
+// Profiles returns the names of the profiles with a stored token, sorted.
+func (p *ProfileStore) Profiles() ([]string, error) {
+	dir, err := p.dir()
+	if err != nil {
+		return nil, err
+	}
+	fis, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, fi := range fis {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), profileExt) || strings.HasPrefix(fi.Name(), ".") {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(fi.Name(), profileExt))
+	}
+	sort.Strings(names)
+	return names, nil
+}
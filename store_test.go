@@ -0,0 +1,120 @@
+package robinhood_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"astuart.co/go-robinhood/v2"
+	"astuart.co/go-robinhood/v2/robinhoodtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestCredsCacherMemoryStore(t *testing.T) {
+	s := robinhoodtest.NewServer()
+	defer s.Close()
+
+	ep := s.Endpoints()
+	st := &robinhood.MemoryStore{}
+	cc := &robinhood.CredsCacher{Creds: &robinhood.OAuth{Username: "user", Password: "password", Endpoints: &ep}, Store: st}
+
+	tok, err := cc.Token()
+	require.NoError(t, err)
+	stored, err := st.Load()
+	require.NoError(t, err)
+	assert.Equal(t, tok.AccessToken, stored.AccessToken)
+
+	_, err = cc.Token()
+	require.NoError(t, err)
+	assert.Len(t, s.Grants(), 1)
+
+	require.NoError(t, cc.Invalidate())
+	stored, err = st.Load()
+	require.NoError(t, err)
+	assert.Empty(t, stored.AccessToken)
+	assert.Equal(t, tok.RefreshToken, stored.RefreshToken)
+
+	_, err = cc.Token()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"password", "refresh_token"}, s.Grants())
+}
+
+func TestEnvStore(t *testing.T) {
+	st := &robinhood.EnvStore{Var: "ROBINHOOD_TEST_TOKEN"}
+
+	t.Setenv("ROBINHOOD_TEST_TOKEN", "")
+	tok, err := st.Load()
+	require.NoError(t, err)
+	assert.Nil(t, tok)
+
+	t.Setenv("ROBINHOOD_TEST_TOKEN", "bare-access\n")
+	tok, err = st.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "bare-access", tok.AccessToken)
+	assert.True(t, tok.Valid())
+
+	exp := time.Now().Add(time.Hour).Round(time.Second)
+	require.NoError(t, st.Save(&oauth2.Token{AccessToken: "json-access", RefreshToken: "json-refresh", Expiry: exp}))
+	tok, err = st.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "json-access", tok.AccessToken)
+	assert.Equal(t, "json-refresh", tok.RefreshToken)
+	assert.True(t, exp.Equal(tok.Expiry))
+
+	t.Setenv("ROBINHOOD_TEST_TOKEN", "{not json")
+	_, err = st.Load()
+	assert.Error(t, err)
+
+	require.NoError(t, st.Delete())
+	_, ok := os.LookupEnv("ROBINHOOD_TEST_TOKEN")
+	assert.False(t, ok)
+}
+
+func TestProfileStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "profiles")
+
+	none, err := (&robinhood.ProfileStore{Dir: dir}).Profiles()
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	work := &robinhood.ProfileStore{Dir: dir, Name: "work"}
+	require.NoError(t, work.Save(&oauth2.Token{AccessToken: "work-access"}))
+	def := &robinhood.ProfileStore{Dir: dir}
+	require.NoError(t, def.Save(&oauth2.Token{AccessToken: "default-access"}))
+
+	tok, err := work.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "work-access", tok.AccessToken)
+	tok, err = def.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "default-access", tok.AccessToken)
+
+	names, err := def.Profiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default", "work"}, names)
+
+	require.NoError(t, work.Delete())
+	tok, err = work.Load()
+	require.NoError(t, err)
+	assert.Nil(t, tok)
+
+	_, err = (&robinhood.ProfileStore{Dir: dir, Name: "../escape"}).Load()
+	assert.Error(t, err)
+}
+
+func TestFileStoreDefaultPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	assert.Equal(t, filepath.Join(home, ".config", "robinhood.token"), robinhood.DefaultTokenPath())
+
+	st := &robinhood.FileStore{}
+	require.NoError(t, st.Save(&oauth2.Token{AccessToken: "access"}))
+	tok, err := st.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "access", tok.AccessToken)
+	assert.FileExists(t, filepath.Join(home, ".config", "robinhood.token"))
+}
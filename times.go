@@ -1,6 +1,10 @@
 package robinhood
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // Common constants for hours and minutes from midnight at which market events
 // occur.
@@ -19,6 +23,60 @@ const (
 	MinExtendedClose   = HrExtendedClose * 60
 )
 
+// timeFormats are the layouts in which the API has been seen to send times,
+// tried in order. Times without a zone are UTC.
+var timeFormats = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	dateFormat,
+}
+
+// Timestamp is a point in time sent by the API, such as when an order was
+// last updated. Unlike time.Time, it decodes any of the formats the API uses,
+// and decodes null and "" as the zero Timestamp.
+type Timestamp struct {
+	time.Time
+}
+
+// MarshalJSON implements json.Marshaler. The zero Timestamp is encoded as
+// null.
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte("\"" + t.Format(time.RFC3339Nano) + "\""), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Timestamp) UnmarshalJSON(bs []byte) error {
+	tm, err := parseTime(bs)
+	if err != nil {
+		return err
+	}
+	t.Time = tm
+	return nil
+}
+
+// parseTime parses a JSON string in any of timeFormats.
+func parseTime(bs []byte) (time.Time, error) {
+	s := strings.TrimSpace(string(bs))
+	if s == "null" {
+		return time.Time{}, nil
+	}
+	s = strings.Trim(s, "\"")
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, f := range timeFormats {
+		if t, err := time.Parse(f, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
 // MinuteOfDay returns the minute of the day for a given time.Time (hr * 60 +
 // min).
 func MinuteOfDay(t time.Time) int {
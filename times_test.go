@@ -0,0 +1,64 @@
+package robinhood_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"astuart.co/go-robinhood/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestampFormats(t *testing.T) {
+	want := time.Date(2021, 3, 4, 15, 30, 0, 0, time.UTC)
+	for _, s := range []string{
+		`"2021-03-04T15:30:00Z"`,
+		`"2021-03-04T15:30:00.000000Z"`,
+		`"2021-03-04T10:30:00-05:00"`,
+		`"2021-03-04T15:30:00"`,
+		`"2021-03-04 15:30:00"`,
+	} {
+		var ts robinhood.Timestamp
+		require.NoError(t, json.Unmarshal([]byte(s), &ts), s)
+		assert.True(t, want.Equal(ts.Time), "%s: %v", s, ts)
+	}
+
+	for _, s := range []string{`null`, `""`} {
+		ts := robinhood.Timestamp{Time: want}
+		require.NoError(t, json.Unmarshal([]byte(s), &ts), s)
+		assert.True(t, ts.IsZero(), s)
+	}
+
+	var ts robinhood.Timestamp
+	assert.Error(t, json.Unmarshal([]byte(`"yesterday"`), &ts))
+
+	bs, err := json.Marshal(robinhood.Timestamp{Time: want})
+	require.NoError(t, err)
+	assert.Equal(t, `"2021-03-04T15:30:00Z"`, string(bs))
+	bs, err = json.Marshal(robinhood.Timestamp{})
+	require.NoError(t, err)
+	assert.Equal(t, `null`, string(bs))
+}
+
+func TestDateFormats(t *testing.T) {
+	var v struct {
+		Issue   robinhood.Date `json:"issue_date"`
+		Flagged robinhood.Date `json:"marked_pattern_day_trader_date"`
+		Start   robinhood.Date `json:"start_date"`
+	}
+	err := json.Unmarshal([]byte(`{"issue_date":"2021-03-04","marked_pattern_day_trader_date":null,"start_date":"2021-03-04T23:00:00Z"}`), &v)
+	require.NoError(t, err)
+	assert.Equal(t, "2021-03-04", v.Issue.String())
+	assert.True(t, v.Flagged.IsZero())
+	assert.Equal(t, "2021-03-04", v.Start.String())
+	assert.True(t, v.Issue.Equal(v.Start.Time))
+}
+
+func TestQuoteTimestamps(t *testing.T) {
+	var q robinhood.Quote
+	err := json.Unmarshal([]byte(`{"symbol":"SPY","previous_close_date":"2021-03-03","updated_at":"2021-03-04T21:00:00Z"}`), &q)
+	require.NoError(t, err)
+	assert.Equal(t, "2021-03-03", q.PreviousCloseDate.String())
+	assert.True(t, q.UpdatedAt.After(q.PreviousCloseDate.Time))
+}
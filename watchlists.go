@@ -18,17 +18,7 @@ type Watchlist struct {
 
 // GetWatchlists retrieves the watchlists for a given set of credentials/accounts.
 func (c *Client) GetWatchlists(ctx context.Context) ([]Watchlist, error) {
-	var r struct{ Results []Watchlist }
-	err := c.GetAndDecode(ctx, EPWatchlists, &r)
-	if err != nil {
-		return nil, err
-	}
-	if r.Results != nil {
-		for i := range r.Results {
-			r.Results[i].Client = c
-		}
-	}
-	return r.Results, nil
+	return c.IterWatchlists().All(ctx)
 }
 
 // GetInstruments returns the list of Instruments associated with a Watchlist.